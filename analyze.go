@@ -0,0 +1,172 @@
+package algo
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// gtpAnalyzeInterval is the time between two reports when the
+// controller gives none
+const gtpAnalyzeInterval = time.Second
+
+// gtpAnalyzeCommands are the analysis commands, true for the KataGo
+// format
+var gtpAnalyzeCommands = map[string]bool{
+	"lz-analyze":   false,
+	"kata-analyze": true,
+}
+
+// gtpAnalysis is how an analysis is reported
+type gtpAnalysis struct {
+	// node is searched, the current node or the pass of the other
+	// player when the analysis is for the player not to move
+	node      *TreeNode
+	kata      bool
+	interval  time.Duration
+	ownership bool
+}
+
+// parseAnalysis reads [color] [interval] of lz-analyze, and
+// ownership true|false of kata-analyze, the interval in centiseconds
+func (e *GTP) parseAnalysis(name string, args []string) (*gtpAnalysis, error) {
+	if e.head == nil {
+		if err := e.newTree(); err != nil {
+			return nil, err
+		}
+	}
+	a := &gtpAnalysis{node: e.node, kata: gtpAnalyzeCommands[name], interval: gtpAnalyzeInterval}
+	for i := 0; i < len(args); i++ {
+		arg := strings.ToLower(args[i])
+		if player, err := parseGTPColor(arg); err == nil {
+			if a.node, err = e.nodeFor(player); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if arg == "interval" && i+1 < len(args) {
+			i++
+			arg = args[i]
+		}
+		if cs, err := strconv.Atoi(arg); err == nil && cs >= 0 {
+			if cs > 0 {
+				a.interval = time.Duration(cs) * 10 * time.Millisecond
+			}
+			continue
+		}
+		if arg == "ownership" && a.kata && i+1 < len(args) {
+			i++
+			a.ownership = strings.ToLower(args[i]) == "true"
+			continue
+		}
+		return nil, fmt.Errorf("syntax error")
+	}
+	return a, nil
+}
+
+// newTicker is a time.Ticker of d
+func newTicker(d time.Duration) (<-chan time.Time, func()) {
+	t := time.NewTicker(d)
+	return t.C, t.Stop
+}
+
+// analyze runs MCTS from the current node and writes an info line
+// every interval, until a line is read from lines, which is returned
+func (e *GTP) analyze(a *gtpAnalysis, lines <-chan string, out io.Writer) (string, bool) {
+	search := a.node.MCTS()
+	done := search.Done()
+	ticks, stop := e.ticker(a.interval)
+	defer stop()
+	for {
+		select {
+		case <-ticks:
+			if info := e.analysisInfo(a); info != "" {
+				fmt.Fprintln(out, info)
+			}
+		case <-done:
+			// all searched, wait for the next command
+			done = nil
+		case line, ok := <-lines:
+			search.Stop()
+			return line, ok
+		}
+	}
+}
+
+// analysisInfo returns the info of the searched moves of the node
+// analyzed, the most visited first, and its ownership for kata-analyze,
+// all read at once under the lock of the search. The win rates and
+// ownership are of the player to move, and the prior is even as the
+// search picks the moves at random.
+func (e *GTP) analysisInfo(a *gtpAnalysis) string {
+	root := a.node
+	player := root.NextPlayer()
+	var b strings.Builder
+	root.lock()
+	defer root.unlock()
+	var children []*TreeNode
+	for _, child := range root.children {
+		if child.visitTimes > 0 {
+			children = append(children, child)
+		}
+	}
+	sort.SliceStable(children, func(i, j int) bool {
+		return children[i].visitTimes > children[j].visitTimes
+	})
+	var prior float64
+	if len(root.children) > 0 {
+		prior = 1 / float64(len(root.children))
+	}
+	for order, child := range children {
+		winrate := float64(child.result[player]) / float64(child.visitTimes)
+		if order > 0 {
+			b.WriteString(" ")
+		}
+		fmt.Fprintf(&b, "info move %s visits %d ", gtpAction(child.action), child.visitTimes)
+		if a.kata {
+			fmt.Fprintf(&b, "winrate %.6f prior %.6f", winrate, prior)
+		} else {
+			fmt.Fprintf(&b, "winrate %d prior %d", int(winrate*10000), int(prior*10000))
+		}
+		fmt.Fprintf(&b, " order %d pv", order)
+		for _, action := range child.principalVariation() {
+			fmt.Fprintf(&b, " %s", gtpAction(action))
+		}
+	}
+	if !a.ownership || b.Len() == 0 {
+		return b.String()
+	}
+	if own := root.ownershipMap(); own != nil {
+		b.WriteString(" ownership")
+		// from the top left, the last row first
+		for x := len(own) - 1; x >= 0; x-- {
+			for _, v := range own[x] {
+				if player == PlayerWhite {
+					v = -v
+				}
+				fmt.Fprintf(&b, " %.3f", v)
+			}
+		}
+	}
+	return b.String()
+}
+
+// principalVariation returns the action of node followed by the
+// actions of the most visited children
+func (node *TreeNode) principalVariation() []*Action {
+	var pv []*Action
+	for n := node; n != nil; {
+		pv = append(pv, n.action)
+		var best *TreeNode
+		for _, child := range n.children {
+			if child.visitTimes > 0 && (best == nil || child.visitTimes > best.visitTimes) {
+				best = child
+			}
+		}
+		n = best
+	}
+	return pv
+}
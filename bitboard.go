@@ -0,0 +1,215 @@
+package algo
+
+import (
+	"math/bits"
+	"sync"
+)
+
+// bitboardWords holds the largest board with its guard column
+const bitboardWords = (maxBoardSize*(maxBoardSize+1) + 63) / 64
+
+// bitboard is a set of points, point (x, y) is bit x*stride+y,
+// the stride keeps a guard column so that shifts never wrap into
+// the next row
+type bitboard [bitboardWords]uint64
+
+func (b *bitboard) set(i int) {
+	b[i>>6] |= 1 << uint(i&63)
+}
+
+func (b *bitboard) clear(i int) {
+	b[i>>6] &^= 1 << uint(i&63)
+}
+
+func (b bitboard) has(i int) bool {
+	if i < 0 || i >= bitboardWords*64 {
+		return false
+	}
+	return b[i>>6]&(1<<uint(i&63)) != 0
+}
+
+func (b bitboard) or(o bitboard) bitboard {
+	for i := range b {
+		b[i] |= o[i]
+	}
+	return b
+}
+
+func (b bitboard) and(o bitboard) bitboard {
+	for i := range b {
+		b[i] &= o[i]
+	}
+	return b
+}
+
+func (b bitboard) andNot(o bitboard) bitboard {
+	for i := range b {
+		b[i] &^= o[i]
+	}
+	return b
+}
+
+func (b bitboard) isEmpty() bool {
+	for _, w := range b {
+		if w != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (b bitboard) count() int {
+	n := 0
+	for _, w := range b {
+		n += bits.OnesCount64(w)
+	}
+	return n
+}
+
+// first returns the lowest point in b, -1 for an empty set
+func (b bitboard) first() int {
+	for i, w := range b {
+		if w != 0 {
+			return i<<6 + bits.TrailingZeros64(w)
+		}
+	}
+	return -1
+}
+
+// points lists the points in b in order
+func (b bitboard) points() []int {
+	var res []int
+	for i, w := range b {
+		for w != 0 {
+			res = append(res, i<<6+bits.TrailingZeros64(w))
+			w &= w - 1
+		}
+	}
+	return res
+}
+
+// shl moves every point n bits up, 0 < n < 64
+func (b bitboard) shl(n uint) bitboard {
+	var r bitboard
+	for i := len(b) - 1; i > 0; i-- {
+		r[i] = b[i]<<n | b[i-1]>>(64-n)
+	}
+	r[0] = b[0] << n
+	return r
+}
+
+// shr moves every point n bits down, 0 < n < 64
+func (b bitboard) shr(n uint) bitboard {
+	var r bitboard
+	for i := 0; i < len(b)-1; i++ {
+		r[i] = b[i]>>n | b[i+1]<<(64-n)
+	}
+	r[len(b)-1] = b[len(b)-1] >> n
+	return r
+}
+
+// layout maps the points of a board size to bitboard bits
+type layout struct {
+	width  int
+	height int
+	stride int
+	// mask has all the points on board
+	mask bitboard
+	// zobrist keys by bit and player
+	zobrist [][2]uint64
+}
+
+var (
+	layoutMux sync.Mutex
+	// layouts are made on first use, by width and height
+	layouts = map[[2]int]*layout{}
+)
+
+// layoutOf returns the layout of a board, the size must be valid
+func layoutOf(width, height int) *layout {
+	layoutMux.Lock()
+	defer layoutMux.Unlock()
+	if l, ok := layouts[[2]int{width, height}]; ok {
+		return l
+	}
+	l := &layout{
+		width:   width,
+		height:  height,
+		stride:  width + 1,
+		zobrist: make([][2]uint64, height*(width+1)),
+	}
+	for x := 0; x < height; x++ {
+		for y := 0; y < width; y++ {
+			i := l.index(x, y)
+			l.mask.set(i)
+			l.zobrist[i] = zobristTable[x][y]
+		}
+	}
+	layouts[[2]int{width, height}] = l
+	return l
+}
+
+func (l *layout) index(x, y int) int {
+	return x*l.stride + y
+}
+
+func (l *layout) point(i int) (x, y int) {
+	return i / l.stride, i % l.stride
+}
+
+// coords lists the points in b as x and y
+func (l *layout) coords(b bitboard) [][2]int {
+	var res [][2]int
+	for _, i := range b.points() {
+		x, y := l.point(i)
+		res = append(res, [2]int{x, y})
+	}
+	return res
+}
+
+func (l *layout) onBoard(i int) bool {
+	return l.mask.has(i)
+}
+
+// neighbors returns the points next to i, off-board ones are -1
+func (l *layout) neighbors(i int) [4]int {
+	n := [4]int{i - 1, i + l.stride, i + 1, i - l.stride}
+	for k := range n {
+		if !l.onBoard(n[k]) {
+			n[k] = -1
+		}
+	}
+	return n
+}
+
+// single is the set of the one point i
+func (l *layout) single(i int) bitboard {
+	var b bitboard
+	b.set(i)
+	return b
+}
+
+// dilate grows b by one step in the four directions
+func (l *layout) dilate(b bitboard) bitboard {
+	stride := uint(l.stride)
+	return b.or(b.shl(1)).or(b.shr(1)).
+		or(b.shl(stride)).or(b.shr(stride)).
+		and(l.mask)
+}
+
+// around returns the points next to b but not in b
+func (l *layout) around(b bitboard) bitboard {
+	return l.dilate(b).andNot(b)
+}
+
+// flood grows seed to all the points connected to it within area
+func (l *layout) flood(seed, area bitboard) bitboard {
+	cur := seed.and(area)
+	for {
+		next := l.dilate(cur).and(area)
+		if next == cur {
+			return cur
+		}
+		cur = next
+	}
+}
@@ -0,0 +1,35 @@
+package algo
+
+import "testing"
+
+func Test_bitboardDilate(t *testing.T) {
+	l := layoutOf(5, 5)
+	// the right edge must not leak into the next row
+	b := l.dilate(l.single(l.index(0, 4)))
+	for _, p := range [][]int{{0, 4}, {0, 3}, {1, 4}} {
+		if !b.has(l.index(p[0], p[1])) {
+			t.Error("dilate should have", p, "but:", b.points())
+		}
+	}
+	if b.count() != 3 {
+		t.Error("dilate of a corner should have 3 points, but:", b.points())
+	}
+	b = l.dilate(l.single(l.index(4, 0)))
+	if b.count() != 3 || b.has(l.index(3, 5)) {
+		t.Error("dilate of a corner should have 3 points, but:", b.points())
+	}
+}
+
+func Test_bitboardFlood(t *testing.T) {
+	l := layoutOf(19, 19)
+	var area bitboard
+	for y := 0; y < 19; y++ {
+		area.set(l.index(5, y))
+	}
+	area.set(l.index(6, 18))
+	area.set(l.index(8, 0))
+	b := l.flood(l.single(l.index(5, 0)), area)
+	if b.count() != 20 || b.has(l.index(8, 0)) {
+		t.Error("flood should reach the 20 connected points, but:", b.points())
+	}
+}
@@ -0,0 +1,167 @@
+package algo
+
+// chainPoint is the union-find entry of a point, the root of a chain
+// keeps its size and pseudo liberties: each empty point is counted once
+// for every stone next to it, with the sum and the sum of squares of
+// the liberty points, which is enough to tell a chain in atari
+type chainPoint struct {
+	parent int16
+	// next links the stones of a chain in a circle
+	next int16
+
+	size   int16
+	libs   int16
+	libSum int32
+	libSq  int64
+}
+
+func newChains(l *layout) []chainPoint {
+	return make([]chainPoint, l.stride*l.height)
+}
+
+// find returns the root of the chain at stone i
+func (state *State) find(i int) int {
+	c := state.chain
+	for int(c[i].parent) != i {
+		p := int(c[i].parent)
+		c[i].parent = c[p].parent
+		i = p
+	}
+	return i
+}
+
+// root returns the root of the chain at stone i like find, but leaves
+// the chains untouched for the paths only reading the board
+func (state *State) root(i int) int {
+	c := state.chain
+	for int(c[i].parent) != i {
+		i = int(c[i].parent)
+	}
+	return i
+}
+
+func (state *State) addLib(root, lib int) {
+	c := &state.chain[root]
+	c.libs++
+	c.libSum += int32(lib)
+	c.libSq += int64(lib) * int64(lib)
+}
+
+func (state *State) removeLib(root, lib int) {
+	c := &state.chain[root]
+	c.libs--
+	c.libSum -= int32(lib)
+	c.libSq -= int64(lib) * int64(lib)
+}
+
+// inAtari reports whether the chain has exactly one liberty, that is
+// when all its pseudo liberties are the same point
+func (state *State) inAtari(root int) bool {
+	c := state.chain[root]
+	sum := int64(c.libSum)
+	return c.libs > 0 && int64(c.libs)*c.libSq == sum*sum
+}
+
+// link makes the new stone at i a chain, and joins it with the
+// chains of player next to it
+func (state *State) link(i int, player Player) {
+	l := state.layout
+	state.chain[i] = chainPoint{
+		parent: int16(i),
+		next:   int16(i),
+		size:   1,
+	}
+	all := state.occupied()
+	for _, n := range l.neighbors(i) {
+		switch {
+		case n < 0:
+		case !all.has(n):
+			state.addLib(i, n)
+		default:
+			state.removeLib(state.find(n), i)
+		}
+	}
+	for _, n := range l.neighbors(i) {
+		if n >= 0 && state.stones[player].has(n) {
+			state.union(state.find(i), state.find(n))
+		}
+	}
+}
+
+func (state *State) union(a, b int) {
+	if a == b {
+		return
+	}
+	c := state.chain
+	if c[a].size < c[b].size {
+		a, b = b, a
+	}
+	c[b].parent = int16(a)
+	c[a].size += c[b].size
+	c[a].libs += c[b].libs
+	c[a].libSum += c[b].libSum
+	c[a].libSq += c[b].libSq
+	c[a].next, c[b].next = c[b].next, c[a].next
+}
+
+// chainStones returns the stones of the chain root
+func (state *State) chainStones(root int) []int {
+	stones := []int{root}
+	for i := int(state.chain[root].next); i != root; i = int(state.chain[i].next) {
+		stones = append(stones, i)
+	}
+	return stones
+}
+
+// removeChain takes the chain off the board, giving its points back
+// as liberties to the chains around
+func (state *State) removeChain(root int) []int {
+	stones := state.chainStones(root)
+	for _, i := range stones {
+		state.remove(i)
+	}
+	all := state.occupied()
+	for _, i := range stones {
+		for _, n := range state.layout.neighbors(i) {
+			if n >= 0 && all.has(n) {
+				state.addLib(state.find(n), i)
+			}
+		}
+	}
+	return stones
+}
+
+// relink builds the chains through the given stones again from the
+// bitboards, points without stone are skipped
+func (state *State) relink(seeds []int) {
+	l := state.layout
+	all := state.occupied()
+	var done bitboard
+	for _, s := range seeds {
+		if s < 0 || !all.has(s) || done.has(s) {
+			continue
+		}
+		player := PlayerBlack
+		if state.stones[PlayerWhite].has(s) {
+			player = PlayerWhite
+		}
+		group := l.flood(l.single(s), state.stones[player])
+		done = done.or(group)
+		stones := group.points()
+		root := stones[0]
+		for k, i := range stones {
+			state.chain[i] = chainPoint{
+				parent: int16(root),
+				next:   int16(stones[(k+1)%len(stones)]),
+			}
+		}
+		state.chain[root].size = int16(len(stones))
+		for _, i := range stones {
+			for _, n := range l.neighbors(i) {
+				if n >= 0 && !all.has(n) {
+					state.addLib(root, n)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package algo
+
+import "testing"
+
+// checkChains compares the incremental chains with flood fills
+func checkChains(t *testing.T, st *State) {
+	l := st.layout
+	empty := st.empty()
+	for _, player := range []Player{PlayerBlack, PlayerWhite} {
+		for _, i := range st.stones[player].points() {
+			group := l.flood(l.single(i), st.stones[player])
+			libs := l.around(group).and(empty)
+			root := st.find(i)
+			if int(st.chain[root].size) != group.count() {
+				t.Fatalf("chain at %d should have %d stones, but: %d", i, group.count(), st.chain[root].size)
+			}
+			if len(st.chainStones(root)) != group.count() {
+				t.Fatalf("chain at %d should list %d stones, but: %v", i, group.count(), st.chainStones(root))
+			}
+			if libs.isEmpty() || st.chain[root].libs == 0 {
+				t.Fatalf("chain at %d should not stay without liberty", i)
+			}
+			if st.inAtari(root) != (libs.count() == 1) {
+				t.Fatalf("chain at %d atari should be %t, libs: %v", i, libs.count() == 1, libs.points())
+			}
+		}
+	}
+}
+
+// floodIllegal is isIllegalPoint by flood fills
+func floodIllegal(st *State, i int) bool {
+	l := st.layout
+	all := st.occupied()
+	if all.has(i) || i == st.ko {
+		return true
+	}
+	player := st.nextMovePlayer
+	stone := l.single(i)
+	empty := l.mask.andNot(all.or(stone))
+	own := l.flood(stone, st.stones[player].or(stone))
+	if !l.around(own).and(empty).isEmpty() {
+		return false
+	}
+	opponent := st.stones[player.next()]
+	for _, n := range l.neighbors(i) {
+		if n >= 0 && opponent.has(n) &&
+			l.around(l.flood(l.single(n), opponent)).and(empty).isEmpty() {
+			return false
+		}
+	}
+	return !st.rules.Suicide || own.count() == 1
+}
+
+func Test_chains(t *testing.T) {
+	st := NewState(BoardSizeSmall, RulesChinese)
+	for _, action := range benchGame(BoardSizeSmall, 300) {
+		st = st.MoveTo(action)
+		checkChains(t, st)
+	}
+	st = NewState(BoardSizeSmall, RulesTrompTaylor)
+	for _, action := range benchGame(BoardSizeSmall, 300) {
+		if st.isForbidden(action) {
+			continue
+		}
+		st = st.MoveTo(action)
+		checkChains(t, st)
+		for _, i := range st.layout.mask.points() {
+			if st.isIllegalPoint(i) != floodIllegal(st, i) {
+				t.Fatalf("point %d should be illegal %t", i, floodIllegal(st, i))
+			}
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package algo
+
+import "math/rand"
+
+// deadPlayouts is how many random games the dead stones are estimated by
+const deadPlayouts = 64
+
+// FinalStatus is the status of a stone at the end of the game
+type FinalStatus int
+
+const (
+	FinalAlive FinalStatus = iota
+	FinalDead
+	FinalSeki
+)
+
+func (status FinalStatus) String() string {
+	switch status {
+	case FinalAlive:
+		return "alive"
+	case FinalDead:
+		return "dead"
+	case FinalSeki:
+		return "seki"
+	}
+	return "unknown"
+}
+
+// DeadGroups returns the chains estimated dead, each as its points
+// with x and y.
+func (state *State) DeadGroups() [][][2]int {
+	dead := state.deadStones(state.life())
+	all := dead[PlayerBlack].or(dead[PlayerWhite])
+	var groups [][][2]int
+	for _, i := range all.points() {
+		if state.root(i) != i {
+			continue
+		}
+		groups = append(groups, state.chainOf(i).Stones)
+	}
+	return groups
+}
+
+// FinalStatusList returns the points of the stones with the status,
+// like the final_status_list command of GTP.
+func (state *State) FinalStatusList(status FinalStatus) [][2]int {
+	lf := state.life()
+	dead := state.deadStones(lf)
+	var stones bitboard
+	switch status {
+	case FinalAlive:
+		stones = state.occupied().andNot(dead[PlayerBlack]).
+			andNot(dead[PlayerWhite]).andNot(lf.seki)
+	case FinalDead:
+		stones = dead[PlayerBlack].or(dead[PlayerWhite])
+	case FinalSeki:
+		stones = state.occupied().and(lf.seki)
+	}
+	return state.layout.coords(stones)
+}
+
+// deadStones estimates the dead stones of each player: those inside
+// the pass-alive area of the opponent, and the unsettled chains owned
+// by the opponent more often than not at the end of random games
+func (state *State) deadStones(lf *life) (dead [2]bitboard) {
+	own := state.playoutOwnership(deadPlayouts)
+	settled := lf.alive[PlayerBlack].or(lf.alive[PlayerWhite]).or(lf.seki)
+	for _, player := range []Player{PlayerBlack, PlayerWhite} {
+		dead[player] = state.stones[player].and(lf.area[player.next()])
+		rest := state.stones[player].andNot(settled).andNot(dead[player])
+		for _, i := range rest.points() {
+			if state.root(i) != i {
+				continue
+			}
+			stones := state.chainStones(i)
+			sum := 0.0
+			for _, s := range stones {
+				sum += own[s]
+			}
+			if player == PlayerWhite {
+				sum = -sum
+			}
+			if sum < 0 {
+				for _, s := range stones {
+					dead[player].set(s)
+				}
+			}
+		}
+	}
+	return
+}
+
+// playoutOwnership plays n random games from state, and returns for each
+// point the mean of 1 when Black owns it at the end and -1 for White,
+// the games are seeded by the hash so the same state gives the same
+// estimation
+func (state *State) playoutOwnership(n int) []float64 {
+	r := rand.New(rand.NewSource(int64(state.hash)))
+	own := make([]float64, len(state.chain))
+	for k := 0; k < n; k++ {
+		end := state.playout(r)
+		black, white := end.areas()
+		for _, i := range black.points() {
+			own[i] += 1 / float64(n)
+		}
+		for _, i := range white.points() {
+			own[i] -= 1 / float64(n)
+		}
+	}
+	return own
+}
+
+// playout plays random moves from state until both players pass,
+// never filling their own eyes, and returns the final state
+func (state *State) playout(r *rand.Rand) *State {
+	ns := state.clone()
+	ns.passes = 0
+	ns.resigned = false
+	limit := 3 * state.layout.width * state.layout.height
+	for k := 0; k < limit && ns.passes < 2; k++ {
+		ns.play(ns.randomMove(r))
+	}
+	return ns
+}
+
+// randomMove picks a legal move not filling an own eye, or a pass
+func (state *State) randomMove(r *rand.Rand) *Action {
+	l := state.layout
+	player := state.nextMovePlayer
+	points := state.empty().points()
+	for len(points) > 0 {
+		k := r.Intn(len(points))
+		i := points[k]
+		points[k] = points[len(points)-1]
+		points = points[:len(points)-1]
+		if state.isIllegalPoint(i) || state.isEye(i, player) {
+			continue
+		}
+		x, y := l.point(i)
+		return NewAction(x, y, player)
+	}
+	return NewPassAction(player)
+}
+
+// isEye reports whether the empty point i is surrounded by stones of
+// player, none of them in atari
+func (state *State) isEye(i int, player Player) bool {
+	for _, n := range state.layout.neighbors(i) {
+		if n < 0 {
+			continue
+		}
+		if !state.stones[player].has(n) || state.inAtari(state.root(n)) {
+			return false
+		}
+	}
+	return true
+}
+
+// areas returns the Tromp-Taylor area of each player
+func (state *State) areas() (black, white bitboard) {
+	black, white = state.stones[PlayerBlack], state.stones[PlayerWhite]
+	for _, r := range state.regions() {
+		switch {
+		case r.borders[PlayerBlack] && !r.borders[PlayerWhite]:
+			black = black.or(r.points)
+		case r.borders[PlayerWhite] && !r.borders[PlayerBlack]:
+			white = white.or(r.points)
+		}
+	}
+	return
+}
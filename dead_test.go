@@ -0,0 +1,64 @@
+package algo
+
+import "testing"
+
+func Test_deadGroups(t *testing.T) {
+	// the white stone at 0-2 is left in black's area
+	rows := []string{
+		"..O..",
+		"XXXXX",
+		"OOOOO",
+		".....",
+		".....",
+	}
+	st := setupBoard(RulesJapanese, rows...)
+	groups := st.DeadGroups()
+	if len(groups) != 1 || len(groups[0]) != 1 || groups[0][0] != [2]int{0, 2} {
+		t.Fatal("0-2 should be the only dead group, but:", groups)
+	}
+	dead := st.FinalStatusList(FinalDead)
+	if len(dead) != 1 || dead[0] != [2]int{0, 2} {
+		t.Error("0-2 should be the only dead stone, but:", dead)
+	}
+	if n := len(st.FinalStatusList(FinalAlive)); n != 10 {
+		t.Error("10 stones should be alive, but:", n)
+	}
+	if st.Score() != -16.5 {
+		t.Error("score should count 0-2 alive and row 0 neutral, but:", st.Score())
+	}
+	if st.FinalScore() != -10.5 {
+		t.Error("final score should take 0-2 as prisoner, but:", st.FinalScore())
+	}
+	if st.ResultString() != "W+10.5" {
+		t.Error("result should be W+10.5, but:", st.ResultString())
+	}
+	if c := st.scoreCache; c == nil || c.score != -10.5 || st.FinalScore() != -10.5 {
+		t.Error("final score should be kept for the position, but:", c)
+	}
+	st.rules.Komi = 0.5
+	if st.FinalScore() != -4.5 {
+		t.Error("final score should follow the komi, but:", st.FinalScore())
+	}
+
+	// tromp-taylor keeps every stone on board
+	st = setupBoard(RulesTrompTaylor, rows...)
+	if st.FinalScore() != st.Score() {
+		t.Error("tromp-taylor should not take dead stones off, but:", st.FinalScore())
+	}
+}
+
+func Test_finalSeki(t *testing.T) {
+	st := setupBoard(RulesJapanese,
+		".X.O.",
+		"XX.OO",
+		"XX.OO",
+		"XX.OO",
+		"XX.OO",
+	)
+	if n := len(st.FinalStatusList(FinalSeki)); n != 18 {
+		t.Error("18 stones should be in seki, but:", n)
+	}
+	if len(st.DeadGroups()) != 0 {
+		t.Error("no group should be dead in seki, but:", st.DeadGroups())
+	}
+}
@@ -3,71 +3,92 @@ package main
 import (
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/mapleque/algo"
 )
 
 func main() {
-	size := selectSize()
-	root := algo.NewTree("model", size)
+	width, height := selectSize()
+	root, err := algo.NewRectTree("model", width, height, algo.RulesChinese)
+	if err != nil {
+		panic(err)
+	}
 	algo.SetLogLevel(algo.Info)
 	node := root
-	steps := 0
 	fmt.Println(node.GetState().GetBoard())
-	for node != nil {
+	for !node.GetState().IsGameOver() {
 		node = userMove(node)
-		steps++
 		fmt.Println(
 			fmt.Sprintf(
-				"steps %d, %s",
-				steps,
+				"steps %d, %s, captures B %d W %d",
+				node.GetState().MoveNumber(),
 				node.GetAction(),
+				node.GetState().Captures(algo.PlayerBlack),
+				node.GetState().Captures(algo.PlayerWhite),
 			),
 			node.GetState().GetBoard())
 	}
+	fmt.Printf(
+		"Game over, winner: %s, %s\n",
+		node.GetState().Result(),
+		node.GetState().ResultString(),
+	)
+	saveGame(node, algo.SGFInfo{Black: "human", White: "human"})
+}
+
+// saveGame writes the game to an SGF file in the working directory
+func saveGame(node *algo.TreeNode, info algo.SGFInfo) {
+	now := time.Now()
+	info.Date = now.Format("2006-01-02")
+	file := now.Format("game-20060102-150405.sgf")
+	if err := node.SaveSGF(file, info); err != nil {
+		fmt.Printf("failed to save the game: %v\n", err)
+		return
+	}
+	fmt.Printf("Game saved to %s\n", file)
 }
 
-func selectSize() algo.BoardSize {
+func selectSize() (width, height int) {
 	var op string
-	fmt.Println("Please enter board size:\n\t5(for dev)\t9\t13\t19")
+	fmt.Println("Please enter board size:\n\t5(for dev)\t9\t13\t19\tor 2-25, like 7x9 for width x height")
 	fmt.Scanln(&op)
-	switch op {
-	case "5":
-		return algo.BoardSizeMini
-	case "9":
-		return algo.BoardSizeSmall
-	case "13":
-		return algo.BoardSizeMedium
-	case "19":
-		return algo.BoardSizeLarge
-	default:
-		fmt.Printf("invalid size value: %s, please enter 5 or 9 or 13 or 19\n", op)
+	width, height, err := algo.ParseBoardSize(op)
+	if err != nil {
+		fmt.Printf("invalid size value: %s, %v\n", op, err)
 		return selectSize()
 	}
+	return width, height
 }
 
 func userMove(root *algo.TreeNode) *algo.TreeNode {
-	fmt.Println("Please enter the point you will move, like a1")
+	fmt.Println("Please enter the point you will move, like a1, or pass, resign")
 	var op string
 	fmt.Scanln(&op)
+	switch op {
+	case "pass":
+		return root.FindPass()
+	case "resign":
+		return root.Resign()
+	}
 	if len(op) < 2 {
 		fmt.Printf("invalid move position: %s\n", op)
 		return userMove(root)
 	}
 	y := int(op[0] - 'a')
-	if y < 0 || y > root.GetState().GetBoard().Size() {
-		fmt.Printf("invalid move position: %s\n", op)
-		return userMove(root)
-	}
 	x, err := strconv.Atoi(op[1:])
 	x = x - 1
-	if err != nil || x < 0 || x > root.GetState().GetBoard().Size() {
+	if err != nil {
 		fmt.Printf("invalid move position: %s\n", op)
 		return userMove(root)
 	}
-	node := root.FindChild(x, y)
-	if node == nil {
-		fmt.Printf("invalid move position: %s\n", op)
+	if y < 0 || y >= root.GetState().Width() || x < 0 || x >= root.GetState().Height() {
+		fmt.Printf("invalid move %s: %v\n", op, algo.ErrOutOfBounds)
+		return userMove(root)
+	}
+	node, err := root.Play(algo.NewAction(x, y, root.NextPlayer()))
+	if err != nil {
+		fmt.Printf("invalid move %s: %v\n", op, err)
 		return userMove(root)
 	}
 	return node
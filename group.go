@@ -0,0 +1,109 @@
+package algo
+
+// Chain is a set of connected stones of one player, the points are
+// x and y
+type Chain struct {
+	Player    Player
+	Stones    [][2]int
+	Liberties [][2]int
+}
+
+// InAtari reports whether the chain has one liberty left.
+func (chain *Chain) InAtari() bool {
+	return len(chain.Liberties) == 1
+}
+
+// Region is a set of connected empty points
+type Region struct {
+	Points [][2]int
+	// Borders tells whether stones of each player touch the region
+	Borders [2]bool
+}
+
+// Owner returns the only player whose stones touch the region.
+func (region *Region) Owner() (Player, bool) {
+	switch {
+	case region.Borders[PlayerBlack] && !region.Borders[PlayerWhite]:
+		return PlayerBlack, true
+	case region.Borders[PlayerWhite] && !region.Borders[PlayerBlack]:
+		return PlayerWhite, true
+	}
+	return PlayerBlack, false
+}
+
+// ChainAt returns the chain with a stone at x, y, nil for an empty
+// or off-board point.
+func (state *State) ChainAt(x, y int) *Chain {
+	l := state.layout
+	if x < 0 || x >= l.height || y < 0 || y >= l.width {
+		return nil
+	}
+	i := l.index(x, y)
+	if !state.occupied().has(i) {
+		return nil
+	}
+	return state.chainOf(state.root(i))
+}
+
+// Chains returns all the chains on board.
+func (state *State) Chains() []*Chain {
+	var chains []*Chain
+	for _, i := range state.occupied().points() {
+		if state.root(i) == i {
+			chains = append(chains, state.chainOf(i))
+		}
+	}
+	return chains
+}
+
+// ChainsInAtari returns the chains of player with one liberty left.
+func (state *State) ChainsInAtari(player Player) []*Chain {
+	var chains []*Chain
+	for _, i := range state.stones[player].points() {
+		if state.root(i) == i && state.inAtari(i) {
+			chains = append(chains, state.chainOf(i))
+		}
+	}
+	return chains
+}
+
+// Neighbors returns the points on board next to x, y.
+func (state *State) Neighbors(x, y int) [][2]int {
+	return pRange(state.layout.height, state.layout.width, x, y)
+}
+
+// Neighbors returns the points on board next to x, y.
+func (board Board) Neighbors(x, y int) [][2]int {
+	return pRange(board.Height(), board.Width(), x, y)
+}
+
+// Regions returns the sets of connected empty points with the players
+// touching them.
+func (state *State) Regions() []*Region {
+	var regions []*Region
+	for _, r := range state.regions() {
+		regions = append(regions, &Region{
+			Points:  state.layout.coords(r.points),
+			Borders: r.borders,
+		})
+	}
+	return regions
+}
+
+// chainOf builds the Chain of root
+func (state *State) chainOf(root int) *Chain {
+	l := state.layout
+	player := PlayerBlack
+	if state.stones[PlayerWhite].has(root) {
+		player = PlayerWhite
+	}
+	var stones bitboard
+	for _, i := range state.chainStones(root) {
+		stones.set(i)
+	}
+	return &Chain{
+		Player:    player,
+		Stones:    l.coords(stones),
+		Liberties: l.coords(l.around(stones).and(state.empty())),
+	}
+}
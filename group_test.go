@@ -0,0 +1,81 @@
+package algo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_chainAt(t *testing.T) {
+	st := setupBoard(RulesChinese,
+		"XO...",
+		"XO...",
+		".....",
+		"O....",
+		"....X",
+	)
+	if err := st.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	chains := append([]chainPoint(nil), st.chain...)
+	chain := st.ChainAt(0, 0)
+	if chain == nil || chain.Player != PlayerBlack {
+		t.Fatal("0-0 should be a black chain, but:", chain)
+	}
+	if !reflect.DeepEqual(chain.Stones, [][2]int{{0, 0}, {1, 0}}) {
+		t.Error("black chain should be 0-0 and 1-0, but:", chain.Stones)
+	}
+	if !reflect.DeepEqual(chain.Liberties, [][2]int{{2, 0}}) {
+		t.Error("black chain should have the liberty 2-0, but:", chain.Liberties)
+	}
+	chain = st.ChainAt(0, 1)
+	if !reflect.DeepEqual(chain.Liberties, [][2]int{{0, 2}, {1, 2}, {2, 1}}) {
+		t.Error("white chain liberties should be 0-2 1-2 2-1, but:", chain.Liberties)
+	}
+	if st.ChainAt(3, 3) != nil || st.ChainAt(-1, 0) != nil || st.ChainAt(0, 5) != nil {
+		t.Error("empty and off-board points should have no chain")
+	}
+	if n := len(st.Chains()); n != 4 {
+		t.Error("should have 4 chains, but:", n)
+	}
+	atari := st.ChainsInAtari(PlayerBlack)
+	if len(atari) != 1 || !atari[0].InAtari() || atari[0].Liberties[0] != [2]int{2, 0} {
+		t.Error("0-0 should be in atari at 2-0, but:", atari)
+	}
+	if len(st.ChainsInAtari(PlayerWhite)) != 0 {
+		t.Error("white should have no chain in atari")
+	}
+	if !reflect.DeepEqual(st.chain, chains) {
+		t.Error("reading the chains should not change them")
+	}
+}
+
+func Test_neighborsRegions(t *testing.T) {
+	st, _ := NewRectState(3, 4, RulesChinese)
+	if n := st.Neighbors(3, 2); !reflect.DeepEqual(n, [][2]int{{3, 1}, {2, 2}}) {
+		t.Error("3-2 should be a corner, but:", n)
+	}
+	if n := st.GetBoard().Neighbors(1, 1); len(n) != 4 {
+		t.Error("1-1 should have 4 neighbors, but:", n)
+	}
+	st = setupBoard(RulesChinese,
+		".X...",
+		"XX...",
+		".....",
+		"..OOO",
+		"..O..",
+	)
+	regions := st.Regions()
+	if len(regions) != 3 {
+		t.Fatal("should have 3 regions, but:", len(regions))
+	}
+	for k, e := range []struct {
+		n     int
+		owner Player
+		owned bool
+	}{{1, PlayerBlack, true}, {15, PlayerBlack, false}, {2, PlayerWhite, true}} {
+		owner, owned := regions[k].Owner()
+		if len(regions[k].Points) != e.n || owned != e.owned || (owned && owner != e.owner) {
+			t.Error("region", k, "should be", e, "but:", regions[k])
+		}
+	}
+}
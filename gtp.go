@@ -0,0 +1,638 @@
+package algo
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// gtpColumns are the column letters of GTP vertices, I is left out
+const gtpColumns = "ABCDEFGHJKLMNOPQRSTUVWXYZ"
+
+const (
+	// gtpMainTimeMoves is how many moves the main time is shared by
+	gtpMainTimeMoves = 30
+	// gtpMinThinkTime is the least time a move is searched for
+	gtpMinThinkTime = 50 * time.Millisecond
+)
+
+// GTP is an engine speaking the Go Text Protocol version 2 on a tree,
+// loaded from the checkpoint file given to NewTree.
+type GTP struct {
+	ckfile string
+	rules  Ruleset
+	size   int
+	// komiSet tells the komi was given by the controller, it then
+	// stays over the handicap komi of the rules
+	komiSet bool
+
+	moveTime        time.Duration
+	resignThreshold float64
+	resignMoves     int
+
+	// timeLeft and stonesLeft are told by time_settings and time_left,
+	// the time is unlimited when not known
+	timeKnown  [2]bool
+	timeLeft   [2]time.Duration
+	stonesLeft [2]int
+
+	// ticker gives the times to report an analysis at, with the
+	// function stopping it
+	ticker func(d time.Duration) (<-chan time.Time, func())
+
+	head *TreeNode
+	node *TreeNode
+	quit bool
+}
+
+// gtpHandler runs a command with its arguments
+type gtpHandler func(e *GTP, args []string) (string, error)
+
+var gtpCommands map[string]gtpHandler
+
+func init() {
+	// set here as list_commands and known_command refer to the map
+	gtpCommands = map[string]gtpHandler{
+		"protocol_version":    func(*GTP, []string) (string, error) { return "2", nil },
+		"name":                func(*GTP, []string) (string, error) { return "algo", nil },
+		"version":             func(*GTP, []string) (string, error) { return "0.1", nil },
+		"known_command":       (*GTP).knownCommand,
+		"list_commands":       (*GTP).listCommands,
+		"quit":                (*GTP).quitCommand,
+		"boardsize":           (*GTP).boardsize,
+		"clear_board":         (*GTP).clearBoard,
+		"komi":                (*GTP).komi,
+		"fixed_handicap":      (*GTP).fixedHandicap,
+		"place_free_handicap": (*GTP).fixedHandicap,
+		"set_free_handicap":   (*GTP).setFreeHandicap,
+		"play":                (*GTP).play,
+		"genmove":             (*GTP).genmove,
+		"undo":                (*GTP).undo,
+		"showboard":           (*GTP).showboard,
+		"final_score":         (*GTP).finalScore,
+		"final_status_list":   (*GTP).finalStatusList,
+		"time_settings":       (*GTP).timeSettings,
+		"time_left":           (*GTP).timeLeftCommand,
+	}
+}
+
+// NewGTP makes an engine on a 19x19 board, the trees are loaded from
+// the checkpoint files named by ckfile, none when it is empty.
+func NewGTP(ckfile string, rules Ruleset) *GTP {
+	return &GTP{
+		ckfile:   ckfile,
+		rules:    rules,
+		size:     int(BoardSizeLarge),
+		moveTime: 5 * time.Second,
+		ticker:   newTicker,
+	}
+}
+
+// SetMoveTime sets the time of a move when there is no time limit, and
+// the most a move takes otherwise.
+func (e *GTP) SetMoveTime(d time.Duration) {
+	e.moveTime = d
+}
+
+// SetResign makes the engine resign, see TreeNode.SetResign.
+func (e *GTP) SetResign(threshold float64, moves int) {
+	e.resignThreshold = threshold
+	e.resignMoves = moves
+	if e.head != nil {
+		e.head.SetResign(threshold, moves)
+	}
+}
+
+// Run answers the commands read from in on out until quit or the end
+// of in. An analysis goes on until the next command is read.
+func (e *GTP) Run(in io.Reader, out io.Writer) error {
+	lines := make(chan string)
+	done := make(chan struct{})
+	defer close(done)
+	var scanErr error
+	go func() {
+		defer close(lines)
+		sc := bufio.NewScanner(in)
+		for sc.Scan() {
+			select {
+			case lines <- sc.Text():
+			case <-done:
+				return
+			}
+		}
+		scanErr = sc.Err()
+	}()
+	line, ok := <-lines
+	for ok {
+		id, name, args, isCommand := parseGTPLine(line)
+		line, ok = "", false
+		if !isCommand {
+			line, ok = <-lines
+			continue
+		}
+		if _, analyzing := gtpAnalyzeCommands[name]; analyzing {
+			a, err := e.parseAnalysis(name, args)
+			if err != nil {
+				fmt.Fprintf(out, "?%s %s\n\n", id, err)
+				line, ok = <-lines
+				continue
+			}
+			fmt.Fprintf(out, "=%s\n", id)
+			line, ok = e.analyze(a, lines, out)
+			fmt.Fprint(out, "\n")
+			continue
+		}
+		res, err := e.exec(name, args)
+		if err != nil {
+			fmt.Fprintf(out, "?%s %s\n\n", id, gtpText(err.Error()))
+		} else {
+			fmt.Fprintf(out, "=%s %s\n\n", id, gtpText(res))
+		}
+		if e.quit {
+			return nil
+		}
+		line, ok = <-lines
+	}
+	return scanErr
+}
+
+// parseGTPLine returns the id, name and arguments of a command, ok is
+// false for a line without one
+func parseGTPLine(line string) (id, name string, args []string, ok bool) {
+	if i := strings.IndexByte(line, '#'); i >= 0 {
+		line = line[:i]
+	}
+	line = strings.Map(func(r rune) rune {
+		switch {
+		case r == '\t':
+			return ' '
+		case r < ' ' || r == 127:
+			return -1
+		}
+		return r
+	}, line)
+	fields := strings.Fields(line)
+	if len(fields) > 0 {
+		if _, err := strconv.Atoi(fields[0]); err == nil {
+			id, fields = fields[0], fields[1:]
+		}
+	}
+	if len(fields) == 0 {
+		return "", "", nil, false
+	}
+	return id, strings.ToLower(fields[0]), fields[1:], true
+}
+
+// gtpText keeps a response from ending early at an empty line
+func gtpText(s string) string {
+	s = strings.TrimRight(s, "\n")
+	for strings.Contains(s, "\n\n") {
+		s = strings.Replace(s, "\n\n", "\n", -1)
+	}
+	return s
+}
+
+func (e *GTP) exec(name string, args []string) (string, error) {
+	handler, ok := gtpCommands[name]
+	if !ok {
+		return "", fmt.Errorf("unknown command")
+	}
+	if e.head == nil && name != "boardsize" && name != "quit" {
+		if err := e.newTree(); err != nil {
+			return "", err
+		}
+	}
+	return handler(e, args)
+}
+
+// newTree starts the game on an empty board
+func (e *GTP) newTree() error {
+	head, err := NewRectTree(e.ckfile, e.size, e.size, e.rules)
+	if err != nil {
+		return err
+	}
+	if e.ckfile != "" {
+		head.LoadCheckpoint()
+	}
+	head.SetResign(e.resignThreshold, e.resignMoves)
+	e.head, e.node = head, head
+	e.applyKomi()
+	return nil
+}
+
+// applyKomi gives the tree the komi of the controller, if it gave one
+func (e *GTP) applyKomi() {
+	if e.komiSet {
+		e.head.setKomi(e.rules.Komi)
+	}
+}
+
+// nodeFor returns the node where player moves: the current node, or
+// the other player passing from it
+func (e *GTP) nodeFor(player Player) (*TreeNode, error) {
+	if player == e.node.NextPlayer() {
+		return e.node, nil
+	}
+	return e.node.Play(NewPassAction(player.next()))
+}
+
+func (e *GTP) knownCommand(args []string) (string, error) {
+	if len(args) > 0 {
+		name := strings.ToLower(args[0])
+		if _, ok := gtpAnalyzeCommands[name]; ok || gtpCommands[name] != nil {
+			return "true", nil
+		}
+	}
+	return "false", nil
+}
+
+func (e *GTP) listCommands(args []string) (string, error) {
+	var names []string
+	for name := range gtpCommands {
+		names = append(names, name)
+	}
+	for name := range gtpAnalyzeCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, "\n"), nil
+}
+
+func (e *GTP) quitCommand(args []string) (string, error) {
+	e.quit = true
+	return "", nil
+}
+
+func (e *GTP) boardsize(args []string) (string, error) {
+	if len(args) < 1 {
+		return "", fmt.Errorf("syntax error")
+	}
+	size, err := strconv.Atoi(args[0])
+	if err != nil {
+		return "", fmt.Errorf("syntax error")
+	}
+	if checkBoardSize(size, size) != nil {
+		return "", fmt.Errorf("unacceptable size")
+	}
+	e.size = size
+	return "", e.newTree()
+}
+
+// clearBoard goes back to the root, the tree searched so far is kept
+// unless handicap stones were placed
+func (e *GTP) clearBoard(args []string) (string, error) {
+	if e.head.state.handicap > 0 {
+		return "", e.newTree()
+	}
+	e.node = e.head
+	e.applyKomi()
+	// the low moves of the last game do not count
+	e.head.SetResign(e.resignThreshold, e.resignMoves)
+	return "", nil
+}
+
+func (e *GTP) komi(args []string) (string, error) {
+	if len(args) < 1 {
+		return "", fmt.Errorf("syntax error")
+	}
+	komi, err := strconv.ParseFloat(args[0], 64)
+	if err != nil {
+		return "", fmt.Errorf("syntax error")
+	}
+	e.rules.Komi = komi
+	e.komiSet = true
+	if e.head.state.rules.Komi == komi {
+		return "", nil
+	}
+	if e.node == e.head && e.head.state.handicap == 0 {
+		// a new game loads the checkpoint searched at the komi
+		return "", e.newTree()
+	}
+	e.head.setKomi(komi)
+	return "", nil
+}
+
+// setKomi changes the komi of every node under root, and drops what
+// was searched at another komi
+func (root *TreeNode) setKomi(komi float64) {
+	if root.state.rules.Komi == komi {
+		return
+	}
+	root.state.rules.Komi = komi
+	root.visitTimes = 0
+	root.result = [2]int{}
+	root.allRollout = false
+	root.uct = 0
+	root.ownership = nil
+	root.owned = 0
+	for _, child := range root.children {
+		child.setKomi(komi)
+	}
+}
+
+func (e *GTP) fixedHandicap(args []string) (string, error) {
+	if len(args) < 1 {
+		return "", fmt.Errorf("syntax error")
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil {
+		return "", fmt.Errorf("syntax error")
+	}
+	if e.node != e.head || e.head.state.handicap > 0 {
+		return "", fmt.Errorf("board not empty")
+	}
+	points, err := handicapPoints(e.size, e.size, n)
+	if err != nil {
+		return "", fmt.Errorf("invalid number of stones")
+	}
+	if err := e.placeHandicap(points); err != nil {
+		return "", err
+	}
+	var vertices []string
+	for _, p := range points {
+		vertices = append(vertices, gtpVertex(p[0], p[1]))
+	}
+	return strings.Join(vertices, " "), nil
+}
+
+func (e *GTP) setFreeHandicap(args []string) (string, error) {
+	if e.node != e.head || e.head.state.handicap > 0 {
+		return "", fmt.Errorf("board not empty")
+	}
+	var points [][2]int
+	for _, arg := range args {
+		x, y, pass, err := e.parseVertex(arg)
+		if err != nil || pass {
+			return "", fmt.Errorf("syntax error")
+		}
+		points = append(points, [2]int{x, y})
+	}
+	if err := e.placeHandicap(points); err != nil {
+		return "", fmt.Errorf("bad vertex list")
+	}
+	return "", nil
+}
+
+func (e *GTP) placeHandicap(points [][2]int) error {
+	if err := e.head.PlaceFreeHandicap(points); err != nil {
+		return err
+	}
+	if !e.komiSet {
+		e.head.setKomi(e.rules.HandicapKomi(len(points)))
+	}
+	return nil
+}
+
+func (e *GTP) play(args []string) (string, error) {
+	if len(args) < 2 {
+		return "", fmt.Errorf("syntax error")
+	}
+	player, err := parseGTPColor(args[0])
+	if err != nil {
+		return "", err
+	}
+	x, y, pass, err := e.parseVertex(args[1])
+	if err != nil {
+		return "", err
+	}
+	action := NewAction(x, y, player)
+	if pass {
+		action = NewPassAction(player)
+	}
+	// moves of the same colour in a row set up stones, the other
+	// player passes in between
+	node, err := e.nodeFor(player)
+	if err == nil {
+		node, err = node.Play(action)
+	}
+	if err != nil {
+		log.Warnf("illegal move %s %s: %v", args[0], args[1], err)
+		return "", fmt.Errorf("illegal move")
+	}
+	e.node = node
+	return "", nil
+}
+
+// genmove searches the position for the time of the move and plays
+// the best move, a resignation is told but not played
+func (e *GTP) genmove(args []string) (string, error) {
+	if len(args) < 1 {
+		return "", fmt.Errorf("syntax error")
+	}
+	player, err := parseGTPColor(args[0])
+	if err != nil {
+		return "", err
+	}
+	if e.node.state.IsGameOver() {
+		return "pass", nil
+	}
+	// the other player passes when it is to move
+	if e.node, err = e.nodeFor(player); err != nil {
+		return "", err
+	}
+	if e.node.state.IsGameOver() {
+		return "pass", nil
+	}
+	e.think(e.thinkTime(player))
+	best := e.node.BestMove()
+	switch {
+	case best == nil:
+		best, err = e.node.Play(NewPassAction(player))
+		if err != nil {
+			return "", err
+		}
+	case best.action.IsResign():
+		return "resign", nil
+	}
+	e.node.Commit()
+	e.node = best
+	return gtpAction(best.action), nil
+}
+
+// think runs MCTS from the current node for d at most
+func (e *GTP) think(d time.Duration) {
+	search := e.node.MCTS()
+	select {
+	case <-time.After(d):
+		search.Stop()
+	case <-search.Done():
+	}
+}
+
+// thinkTime shares the time left of player over the moves to come,
+// up to the move time
+func (e *GTP) thinkTime(player Player) time.Duration {
+	d := e.moveTime
+	if e.timeKnown[player] {
+		moves := gtpMainTimeMoves
+		if e.stonesLeft[player] > 0 {
+			moves = e.stonesLeft[player]
+		}
+		// keep a tenth for the answer to reach the controller
+		if t := e.timeLeft[player] * 9 / 10 / time.Duration(moves); t < d {
+			d = t
+		}
+	}
+	if d < gtpMinThinkTime {
+		d = gtpMinThinkTime
+	}
+	return d
+}
+
+func (e *GTP) undo(args []string) (string, error) {
+	if e.node.parent == nil {
+		return "", fmt.Errorf("cannot undo")
+	}
+	e.node = e.node.parent
+	return "", nil
+}
+
+func (e *GTP) showboard(args []string) (string, error) {
+	return "\n" + gtpBoard(e.node.state.GetBoard()), nil
+}
+
+// gtpBoard draws the board by GTP vertices: the columns without I, and
+// row 1 at the bottom
+func gtpBoard(board Board) string {
+	str := "   "
+	for y := 0; y < board.Width(); y++ {
+		str += string(gtpColumns[y]) + " "
+	}
+	for x := board.Height() - 1; x >= 0; x-- {
+		str += fmt.Sprintf("\n%2d ", x+1)
+		for y := range board[x] {
+			str += board.cell(x, y)
+		}
+	}
+	return str
+}
+
+func (e *GTP) finalScore(args []string) (string, error) {
+	return e.node.state.ResultString(), nil
+}
+
+// finalStatusList lists the dead stones a group on each line, and the
+// others on one line
+func (e *GTP) finalStatusList(args []string) (string, error) {
+	if len(args) < 1 {
+		return "", fmt.Errorf("syntax error")
+	}
+	vertices := func(points [][2]int) string {
+		var res []string
+		for _, p := range points {
+			res = append(res, gtpVertex(p[0], p[1]))
+		}
+		return strings.Join(res, " ")
+	}
+	switch strings.ToLower(args[0]) {
+	case "alive":
+		return vertices(e.node.state.FinalStatusList(FinalAlive)), nil
+	case "seki":
+		return vertices(e.node.state.FinalStatusList(FinalSeki)), nil
+	case "dead":
+		var lines []string
+		for _, group := range e.node.state.DeadGroups() {
+			lines = append(lines, vertices(group))
+		}
+		return strings.Join(lines, "\n"), nil
+	}
+	return "", fmt.Errorf("syntax error")
+}
+
+// timeSettings sets the time of both players, byo-yomi time without
+// stones means no limit
+func (e *GTP) timeSettings(args []string) (string, error) {
+	if len(args) < 3 {
+		return "", fmt.Errorf("syntax error")
+	}
+	var v [3]int
+	for i := range v {
+		n, err := strconv.Atoi(args[i])
+		if err != nil || n < 0 {
+			return "", fmt.Errorf("syntax error")
+		}
+		v[i] = n
+	}
+	mainTime, byoYomi, stones := v[0], v[1], v[2]
+	for p := range e.timeKnown {
+		e.timeKnown[p] = !(byoYomi > 0 && stones == 0)
+		e.timeLeft[p] = time.Duration(mainTime) * time.Second
+		e.stonesLeft[p] = 0
+		if mainTime == 0 && stones > 0 {
+			e.timeLeft[p] = time.Duration(byoYomi) * time.Second
+			e.stonesLeft[p] = stones
+		}
+	}
+	return "", nil
+}
+
+func (e *GTP) timeLeftCommand(args []string) (string, error) {
+	if len(args) < 3 {
+		return "", fmt.Errorf("syntax error")
+	}
+	player, err := parseGTPColor(args[0])
+	if err != nil {
+		return "", err
+	}
+	seconds, err := strconv.Atoi(args[1])
+	if err != nil {
+		return "", fmt.Errorf("syntax error")
+	}
+	stones, err := strconv.Atoi(args[2])
+	if err != nil {
+		return "", fmt.Errorf("syntax error")
+	}
+	e.timeKnown[player] = true
+	e.timeLeft[player] = time.Duration(seconds) * time.Second
+	e.stonesLeft[player] = stones
+	return "", nil
+}
+
+func parseGTPColor(s string) (Player, error) {
+	switch strings.ToLower(s) {
+	case "b", "black":
+		return PlayerBlack, nil
+	case "w", "white":
+		return PlayerWhite, nil
+	}
+	return PlayerBlack, fmt.Errorf("invalid color")
+}
+
+// parseVertex reads a vertex like D4, the column D and the row 4
+func (e *GTP) parseVertex(s string) (x, y int, pass bool, err error) {
+	s = strings.ToUpper(s)
+	if s == "PASS" {
+		return 0, 0, true, nil
+	}
+	if len(s) < 2 {
+		return 0, 0, false, fmt.Errorf("invalid coordinate")
+	}
+	y = strings.IndexByte(gtpColumns, s[0])
+	row, err := strconv.Atoi(s[1:])
+	if y < 0 || err != nil {
+		return 0, 0, false, fmt.Errorf("invalid coordinate")
+	}
+	x = row - 1
+	l := e.node.state.layout
+	if x < 0 || x >= l.height || y >= l.width {
+		return 0, 0, false, fmt.Errorf("invalid coordinate")
+	}
+	return x, y, false, nil
+}
+
+// gtpAction returns the vertex of a move, or pass
+func gtpAction(action *Action) string {
+	if action.IsPass() {
+		return "pass"
+	}
+	return gtpVertex(int(action.x), int(action.y))
+}
+
+// gtpVertex returns the GTP vertex of x, y
+func gtpVertex(x, y int) string {
+	return fmt.Sprintf("%c%d", gtpColumns[y], x+1)
+}
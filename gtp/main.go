@@ -0,0 +1,35 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"time"
+
+	"github.com/mapleque/algo"
+)
+
+func main() {
+	model := flag.String("model", "model", "checkpoint file prefix, empty for none")
+	rulesName := flag.String("rules", algo.RulesChinese.Name, "rules of the games")
+	moveTime := flag.Duration("time", 5*time.Second, "time of each move without time settings")
+	resign := flag.Float64("resign", 0.1, "win rate to resign below, 0 for never")
+	flag.Parse()
+
+	rules, err := algo.RulesetByName(*rulesName)
+	if err != nil {
+		panic(err)
+	}
+	// stdout carries the protocol only
+	algo.SetLogOutput(os.Stderr)
+	algo.SetLogLevel(algo.Warn)
+	engine := algo.NewGTP(*model, rules)
+	engine.SetMoveTime(*moveTime)
+	engine.SetResign(*resign, resignMoves)
+	if err := engine.Run(os.Stdin, os.Stdout); err != nil {
+		panic(err)
+	}
+}
+
+// resignMoves is how many moves in a row the engine must be
+// below the resign threshold before it resigns
+const resignMoves = 3
@@ -0,0 +1,283 @@
+package algo
+
+import (
+	"bufio"
+	"io"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// runGTP returns the responses of the engine to the commands
+func runGTP(t *testing.T, e *GTP, commands ...string) []string {
+	var out strings.Builder
+	if err := e.Run(strings.NewReader(strings.Join(commands, "\n")), &out); err != nil {
+		t.Fatal(err)
+	}
+	res := strings.Split(strings.TrimSuffix(out.String(), "\n\n"), "\n\n")
+	if len(res) != len(commands) {
+		t.Fatalf("should answer %d commands, but:\n%s", len(commands), out.String())
+	}
+	return res
+}
+
+func Test_gtp(t *testing.T) {
+	e := NewGTP("", RulesChinese)
+	e.SetMoveTime(20 * time.Millisecond)
+	res := runGTP(t, e,
+		"1 protocol_version",
+		"boardsize 5",
+		"boardsize 30",
+		"clear_board # new game",
+		"komi 0.5",
+		"play b C3",
+		"play w C3",
+		"play b D4",
+		"play w B2",
+		"2 undo",
+		"known_command genmove",
+		"foo",
+		"time_settings 0 10 5",
+		"genmove w",
+		"final_score",
+		"quit",
+	)
+	expect := []string{
+		"=1 2",
+		"= ",
+		"? unacceptable size",
+		"= ",
+		"= ",
+		"= ",
+		"? illegal move",
+		"= ",
+		"= ",
+		"=2 ",
+		"= true",
+		"? unknown command",
+		"= ",
+	}
+	for i, s := range expect {
+		if res[i] != s {
+			t.Errorf("response %d should be %q, but: %q", i, s, res[i])
+		}
+	}
+	// black D4 after white passing, and white after it
+	if e.node.state.MoveNumber() != 4 || e.node.NextPlayer() != PlayerBlack {
+		t.Error("white should have played, but:", res[13], e.node.state)
+	}
+	if !strings.HasPrefix(res[14], "= B+") && !strings.HasPrefix(res[14], "= W+") {
+		t.Error("wrong score:", res[14])
+	}
+	if e.node.state.Rules().Komi != 0.5 {
+		t.Error("komi should be 0.5, but:", e.node.state.Rules().Komi)
+	}
+}
+
+func Test_gtpHandicap(t *testing.T) {
+	e := NewGTP("", RulesChinese)
+	res := runGTP(t, e,
+		"boardsize 9",
+		"komi 3",
+		"fixed_handicap 2",
+		"play b E5",
+		"showboard",
+		"clear_board",
+		"set_free_handicap A1 J9 E5",
+	)
+	if res[2] != "= G3 C7" {
+		t.Error("wrong handicap:", res[2])
+	}
+	if res[3] != "= " {
+		t.Error("black should play again after white passing, but:", res[3])
+	}
+	board := strings.Split(res[4], "\n")
+	if strings.Contains(res[4], "\n\n") || board[0] != "= " || len(board) != 11 {
+		t.Fatal("wrong board:", res[4])
+	}
+	// row 9 at the top, E5 in the middle and the column J without I
+	if strings.TrimSpace(board[1]) != "A B C D E F G H J" || !strings.HasPrefix(board[2], " 9 ") ||
+		!strings.HasPrefix(board[6], " 5 ") || !strings.Contains(board[6], "\u26AB") ||
+		!strings.Contains(board[8], "\u26AB") {
+		t.Error("wrong board:", res[4])
+	}
+	if res[6] != "= " || e.head.state.Handicap() != 3 || e.head.state.Rules().Komi != 3 {
+		t.Error("free handicap should be placed with komi 3, but:", res[6], e.head.state.Rules().Komi)
+	}
+}
+
+func Test_gtpGenmoveColor(t *testing.T) {
+	e := NewGTP("", RulesChinese)
+	e.SetMoveTime(20 * time.Millisecond)
+	res := runGTP(t, e,
+		"boardsize 5",
+		"komi 2",
+		"play b C3",
+		"genmove b",
+		"clear_board",
+	)
+	if res[3] == "= resign" || !strings.HasPrefix(res[3], "= ") {
+		t.Fatal("black should move, but:", res[3])
+	}
+	if n := e.node; n != e.head {
+		t.Error("clear_board should go back to the root, but:", n)
+	}
+	node := e.head.FindChild(2, 2).FindPass()
+	if node.GetN() == 0 || len(node.children) == 0 {
+		t.Error("white should pass before the black move, but:", node)
+	}
+	if e.head.state.Rules().Komi != 2 {
+		t.Error("komi should be 2, but:", e.head.state.Rules().Komi)
+	}
+}
+
+func Test_gtpKomi(t *testing.T) {
+	SetLogLevel(Error)
+	prefix := filepath.Join(t.TempDir(), "model")
+	root, _ := NewRectTree(prefix, 5, 5, RulesChinese)
+	root.FindChild(2, 2).backpropagate(PlayerBlack)
+	root.SaveCheckpoint()
+
+	e := NewGTP(prefix, RulesChinese)
+	e.SetResign(0.1, 2)
+	runGTP(t, e, "boardsize 5", "komi 6.5")
+	if cc := e.head.findChild(2, 2); cc != nil && cc.GetN() > 0 {
+		t.Error("statistics searched at komi 7.5 should not be kept, but:", cc)
+	}
+	runGTP(t, e, "komi 7.5")
+	if cc := e.head.findChild(2, 2); cc == nil || cc.GetN() != 1 {
+		t.Error("checkpoint of komi 7.5 should be loaded, but:", cc)
+	}
+	runGTP(t, e, "play b C3", "komi 0.5")
+	if e.node.state.Rules().Komi != 0.5 || e.head.findChild(2, 2).GetN() != 0 {
+		t.Error("komi should change and the statistics be dropped, but:", e.node.state.Rules().Komi)
+	}
+
+	e.head.ctx.lowMoves = [2]int{1, 1}
+	runGTP(t, e, "clear_board")
+	if e.head.ctx.lowMoves != [2]int{} {
+		t.Error("a new game should count the low moves again, but:", e.head.ctx.lowMoves)
+	}
+}
+
+func Test_gtpVertex(t *testing.T) {
+	e := NewGTP("", RulesChinese)
+	e.newTree()
+	for _, c := range []struct {
+		vertex string
+		x, y   int
+	}{{"A1", 0, 0}, {"h8", 7, 7}, {"J9", 8, 8}, {"T19", 18, 18}} {
+		x, y, pass, err := e.parseVertex(c.vertex)
+		if err != nil || pass || x != c.x || y != c.y {
+			t.Error("wrong vertex", c.vertex, x, y, err)
+		}
+		if gtpVertex(x, y) != strings.ToUpper(c.vertex) {
+			t.Error("wrong vertex of", x, y, gtpVertex(x, y))
+		}
+	}
+	for _, v := range []string{"I5", "U1", "A0", "A20", "A"} {
+		if _, _, _, err := e.parseVertex(v); err == nil {
+			t.Error("should be invalid:", v)
+		}
+	}
+	if _, _, pass, _ := e.parseVertex("PASS"); !pass {
+		t.Error("should be a pass")
+	}
+}
+
+// waitVisits waits for the search to visit node n times
+func waitVisits(node *TreeNode, n int) {
+	for {
+		node.lock()
+		visits := node.visitTimes
+		node.unlock()
+		if visits >= n {
+			return
+		}
+		runtime.Gosched()
+	}
+}
+
+func Test_gtpAnalyze(t *testing.T) {
+	SetLogLevel(Error)
+	e := NewGTP("", RulesChinese)
+	ticks := make(chan time.Time)
+	e.ticker = func(time.Duration) (<-chan time.Time, func()) {
+		return ticks, func() {}
+	}
+	r, w := io.Pipe()
+	outR, outW := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- e.Run(r, outW)
+		outW.Close()
+	}()
+	out := bufio.NewScanner(outR)
+	send := func(command string) {
+		if _, err := io.WriteString(w, command+"\n"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	read := func() string {
+		if !out.Scan() {
+			t.Fatal("should answer, but:", out.Err())
+		}
+		return out.Text()
+	}
+	report := func() []string {
+		waitVisits(e.node, 10)
+		ticks <- time.Time{}
+		return strings.Fields(read())
+	}
+
+	send("boardsize 5")
+	if res := read() + read(); res != "= " {
+		t.Fatal("wrong response:", res)
+	}
+	send("1 lz-analyze b 5")
+	if res := read(); res != "=1" {
+		t.Fatal("wrong response:", res)
+	}
+	for i := 0; i < 2; i++ {
+		fields := report()
+		if len(fields) < 9 || fields[0] != "info" || fields[1] != "move" || fields[3] != "visits" || fields[5] != "winrate" {
+			t.Fatal("wrong info line:", fields)
+		}
+		if rate, err := strconv.Atoi(fields[6]); err != nil || rate < 0 || rate > 10000 {
+			t.Error("wrong win rate:", fields)
+		}
+	}
+
+	send("kata-analyze interval 5 ownership true")
+	if res := read() + read(); res != "=" {
+		t.Fatal("the analysis should end, and another start, but:", res)
+	}
+	fields := report()
+	i := len(fields) - 26
+	if i < 0 || fields[i] != "ownership" {
+		t.Fatal("kata-analyze should report the ownership, but:", fields)
+	}
+	if rate, err := strconv.ParseFloat(fields[6], 64); err != nil || rate < 0 || rate > 1 {
+		t.Error("wrong win rate:", fields[6])
+	}
+
+	node := e.node
+	send("lz-analyze w")
+	if res := read() + read(); res != "=" {
+		t.Fatal("white should be analyzed, but:", res)
+	}
+	send("quit")
+	if res := read() + read() + read(); res != "= " {
+		t.Fatal("wrong response:", res)
+	}
+	w.Close()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if e.node != node {
+		t.Error("the analysis should not change the game")
+	}
+}
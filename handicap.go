@@ -0,0 +1,140 @@
+package algo
+
+import "fmt"
+
+// handicapKomi is the komi of a handicap game before the bonus
+const handicapKomi = 0.5
+
+// HandicapKomi returns the komi of a game of n handicap stones under
+// the rules, which callers use unless a komi was given.
+func (rules Ruleset) HandicapKomi(n int) float64 {
+	komi := handicapKomi
+	switch rules.HandicapBonus {
+	case HandicapBonusN:
+		komi += float64(n)
+	case HandicapBonusNMinusOne:
+		komi += float64(n - 1)
+	}
+	return komi
+}
+
+// HandicapPoints returns the star points of a fixed handicap of n stones,
+// corners first, then the sides and the center as n grows.
+func HandicapPoints(size BoardSize, n int) ([][2]int, error) {
+	return handicapPoints(int(size), int(size), n)
+}
+
+// handicapPoints is HandicapPoints on a board of any width and height,
+// the star lines of the rows and of the columns are taken apart
+func handicapPoints(width, height, n int) ([][2]int, error) {
+	rows, cols := starLines(height), starLines(width)
+	max := 9
+	switch {
+	case len(rows) < 3 || len(cols) < 3:
+		max = 0
+	case width%2 == 0 || height%2 == 0:
+		// no center line on even boards
+		max = 4
+	}
+	if n < 2 || n > max {
+		return nil, fmt.Errorf("invalid handicap %d on board size %dx%d", n, width, height)
+	}
+	top, midX, bottom := rows[0], rows[1], rows[2]
+	left, midY, right := cols[0], cols[1], cols[2]
+	// upper right, lower left, lower right, upper left
+	points := [][2]int{{top, right}, {bottom, left}, {bottom, right}, {top, left}}
+	if n <= 4 {
+		return points[:n], nil
+	}
+	center := [2]int{midX, midY}
+	switch n {
+	case 5:
+		points = append(points, center)
+	case 6, 7:
+		points = append(points, [2]int{midX, left}, [2]int{midX, right})
+	case 8, 9:
+		points = append(points,
+			[2]int{midX, left}, [2]int{midX, right},
+			[2]int{top, midY}, [2]int{bottom, midY},
+		)
+	}
+	if n == 7 || n == 9 {
+		points = append(points, center)
+	}
+	return points, nil
+}
+
+// PlaceHandicap puts a fixed handicap of n stones on the star points.
+func (state *State) PlaceHandicap(n int) error {
+	points, err := handicapPoints(state.layout.width, state.layout.height, n)
+	if err != nil {
+		return err
+	}
+	return state.PlaceFreeHandicap(points)
+}
+
+// PlaceFreeHandicap puts Black stones on the given points of an empty
+// board and hands the first move to White, the komi is left to the
+// caller.
+func (state *State) PlaceFreeHandicap(points [][2]int) error {
+	if state.history.prev != nil || state.handicap > 0 || !state.occupied().isEmpty() {
+		return fmt.Errorf("handicap only goes on an empty board")
+	}
+	if len(points) < 2 {
+		return fmt.Errorf("handicap needs 2 stones at least, but: %d", len(points))
+	}
+	for i, p := range points {
+		x, y := p[0], p[1]
+		if x < 0 || x >= state.layout.height || y < 0 || y >= state.layout.width {
+			return fmt.Errorf("handicap point out of board: %d-%d", x, y)
+		}
+		for _, q := range points[:i] {
+			if p == q {
+				return fmt.Errorf("handicap point is repeated: %d-%d", x, y)
+			}
+		}
+	}
+	for _, p := range points {
+		state.put(state.layout.index(p[0], p[1]), PlayerBlack)
+	}
+	state.handicap = len(points)
+	state.nextMovePlayer = PlayerWhite
+	state.history = &position{hash: state.hash, player: PlayerWhite}
+	return nil
+}
+
+// Handicap returns the number of handicap stones.
+func (state *State) Handicap() int {
+	return state.handicap
+}
+
+// PlaceHandicap puts a fixed handicap on the board of a new tree.
+func (root *TreeNode) PlaceHandicap(n int) error {
+	l := root.state.layout
+	points, err := handicapPoints(l.width, l.height, n)
+	if err != nil {
+		return err
+	}
+	return root.PlaceFreeHandicap(points)
+}
+
+// PlaceFreeHandicap puts handicap stones on the board of a new tree,
+// the children searched for the empty board are dropped.
+func (root *TreeNode) PlaceFreeHandicap(points [][2]int) error {
+	if root.parent != nil {
+		return fmt.Errorf("handicap only goes on the root")
+	}
+	root.lock()
+	defer root.unlock()
+	if err := root.state.PlaceFreeHandicap(points); err != nil {
+		return err
+	}
+	root.children = nil
+	root.total = 0
+	root.visitTimes = 0
+	root.result = [2]int{}
+	root.allRollout = false
+	root.ownership = nil
+	root.owned = 0
+	return nil
+}
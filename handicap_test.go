@@ -0,0 +1,88 @@
+package algo
+
+import "testing"
+
+func Test_handicapPoints(t *testing.T) {
+	for n := 2; n <= 9; n++ {
+		points, err := HandicapPoints(BoardSizeLarge, n)
+		if err != nil || len(points) != n {
+			t.Error("handicap", n, "should have", n, "points, but:", points, err)
+		}
+		for _, p := range points {
+			if !isStarPos(p[0], p[1], int(BoardSizeLarge), int(BoardSizeLarge)) {
+				t.Error("handicap", n, "point should be a star point:", p)
+			}
+		}
+	}
+	points, _ := HandicapPoints(BoardSizeLarge, 2)
+	if points[0] != [2]int{3, 15} || points[1] != [2]int{15, 3} {
+		t.Error("handicap 2 should be upper right and lower left, but:", points)
+	}
+	// D4 and K10 on the 4-4 points of 13x13
+	points, _ = HandicapPoints(BoardSizeMedium, 4)
+	for i, p := range [][2]int{{3, 9}, {9, 3}, {9, 9}, {3, 3}} {
+		if points[i] != p {
+			t.Error("13x13 handicap should be on the 4-4 points, but:", points)
+		}
+	}
+	if _, err := HandicapPoints(BoardSizeMini, 2); err == nil {
+		t.Error("mini board should have no handicap")
+	}
+	if _, err := HandicapPoints(BoardSize(10), 5); err == nil {
+		t.Error("even board should have 4 handicap at most")
+	}
+}
+
+func Test_placeHandicap(t *testing.T) {
+	st := NewState(BoardSizeLarge, RulesChinese)
+	if err := st.PlaceHandicap(4); err != nil {
+		t.Fatal(err)
+	}
+	if st.nextMovePlayer != PlayerWhite {
+		t.Error("white should move first")
+	}
+	if st.Rules().Komi != RulesChinese.Komi || st.Handicap() != 4 {
+		t.Error("komi should be kept, but:", st.Rules().Komi, st.Handicap())
+	}
+	if RulesChinese.HandicapKomi(4) != 4.5 || RulesJapanese.HandicapKomi(4) != 0.5 {
+		t.Error("wrong handicap komi:", RulesChinese.HandicapKomi(4), RulesJapanese.HandicapKomi(4))
+	}
+	if err := st.PlaceHandicap(2); err == nil {
+		t.Error("handicap should not be placed twice")
+	}
+
+	st = NewState(BoardSizeSmall, RulesJapanese)
+	if err := st.PlaceFreeHandicap([][2]int{{0, 0}, {4, 4}, {8, 8}}); err != nil {
+		t.Fatal(err)
+	}
+	if st.nextMovePlayer != PlayerWhite || st.at(4, 4) != BoardStatusBlack {
+		t.Error("free handicap should be placed, but:", st)
+	}
+	st = NewState(BoardSizeSmall, RulesJapanese)
+	if err := st.PlaceFreeHandicap([][2]int{{0, 0}, {0, 0}}); err == nil {
+		t.Error("repeated point should be rejected")
+	}
+	st = NewState(BoardSizeSmall, RulesJapanese)
+	if err := st.AddStones(PlayerWhite, [][2]int{{2, 2}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.PlaceFreeHandicap([][2]int{{2, 2}, {6, 6}}); err == nil {
+		t.Error("handicap should not go on a board with stones")
+	}
+}
+
+func Test_rectHandicap(t *testing.T) {
+	st, _ := NewRectState(9, 13, RulesChinese)
+	if err := st.PlaceHandicap(5); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range [][2]int{{3, 6}, {9, 2}, {9, 6}, {3, 2}, {6, 4}} {
+		if st.at(p[0], p[1]) != BoardStatusBlack {
+			t.Errorf("%d-%d should be a handicap stone, but:%s\n", p[0], p[1], st)
+		}
+	}
+	st, _ = NewRectState(8, 13, RulesChinese)
+	if err := st.PlaceHandicap(5); err == nil {
+		t.Error("an even width should have no center handicap")
+	}
+}
@@ -0,0 +1,250 @@
+package algo
+
+// LifeStatus tells whether a point is settled whatever is played next
+type LifeStatus uint8
+
+const (
+	// LifeUnsettled is a point whose owner is not decided yet
+	LifeUnsettled LifeStatus = iota
+	// LifeBlack is a stone of a pass-alive black chain, or a point
+	// of the area it makes safe, white stones there are dead
+	LifeBlack
+	// LifeWhite is the same for White
+	LifeWhite
+	// LifeSeki is a stone, a shared liberty or an eye of a seki
+	LifeSeki
+)
+
+func (status LifeStatus) String() string {
+	switch status {
+	case LifeUnsettled:
+		return "unsettled"
+	case LifeBlack:
+		return "black"
+	case LifeWhite:
+		return "white"
+	case LifeSeki:
+		return "seki"
+	}
+	return "unknown"
+}
+
+// life is the settled points of a state
+type life struct {
+	// hash is the zobrist hash of the stones it was found for
+	hash uint64
+	// alive is the stones of the pass-alive chains of each player,
+	// area the points those chains make safe
+	alive [2]bitboard
+	area  [2]bitboard
+	seki  bitboard
+}
+
+func (lf *life) settled() bitboard {
+	return lf.alive[PlayerBlack].or(lf.alive[PlayerWhite]).
+		or(lf.area[PlayerBlack]).or(lf.area[PlayerWhite]).
+		or(lf.seki)
+}
+
+// LifeMap returns the life status of each point, indexed like the board.
+func (state *State) LifeMap() [][]LifeStatus {
+	lf := state.life()
+	l := state.layout
+	res := make([][]LifeStatus, l.height)
+	for x := range res {
+		res[x] = make([]LifeStatus, l.width)
+		for y := range res[x] {
+			i := l.index(x, y)
+			switch {
+			case lf.alive[PlayerBlack].has(i) || lf.area[PlayerBlack].has(i):
+				res[x][y] = LifeBlack
+			case lf.alive[PlayerWhite].has(i) || lf.area[PlayerWhite].has(i):
+				res[x][y] = LifeWhite
+			case lf.seki.has(i):
+				res[x][y] = LifeSeki
+			}
+		}
+	}
+	return res
+}
+
+// life finds the settled points once for the stones on board, the
+// rollouts ask for them at every step
+func (state *State) life() *life {
+	if lf := state.lifeCache; lf != nil && lf.hash == state.hash {
+		return lf
+	}
+	lf := &life{hash: state.hash}
+	for _, player := range []Player{PlayerBlack, PlayerWhite} {
+		lf.alive[player], lf.area[player] = state.benson(player)
+	}
+	lf.seki = state.seki(lf)
+	state.lifeCache = lf
+	return lf
+}
+
+// bensonRegion is a connected set of points without stone of the player
+type bensonRegion struct {
+	points bitboard
+	// borders are the chains next to the region, vital the ones
+	// having all its empty points as liberties
+	borders []int
+	vital   []int
+}
+
+// benson finds the chains of player alive even if player always
+// passes, and the regions they enclose where the opponent cannot
+// live, by Benson's algorithm
+func (state *State) benson(player Player) (alive, area bitboard) {
+	l := state.layout
+	own := state.stones[player]
+	empty := state.empty()
+
+	// chains with the points next to them, and the chain of each stone
+	var chains, reach []bitboard
+	index := make([]int, len(state.chain))
+	for i := range index {
+		index[i] = -1
+	}
+	for _, i := range own.points() {
+		if index[i] >= 0 {
+			continue
+		}
+		var c bitboard
+		for _, s := range state.chainStones(state.root(i)) {
+			c.set(s)
+			index[s] = len(chains)
+		}
+		chains = append(chains, c)
+		reach = append(reach, l.dilate(c))
+	}
+	var regions []*bensonRegion
+	vital := make([][]int, len(chains))
+	// seen marks the chains already found next to region n with n+1
+	seen := make([]int, len(chains))
+	for rest := l.mask.andNot(own); !rest.isEmpty(); {
+		r := &bensonRegion{points: l.flood(l.single(rest.first()), rest)}
+		rest = rest.andNot(r.points)
+		libs := r.points.and(empty)
+		for _, i := range l.around(r.points).points() {
+			k := index[i]
+			if seen[k] == len(regions)+1 {
+				continue
+			}
+			seen[k] = len(regions) + 1
+			r.borders = append(r.borders, k)
+			if libs.andNot(reach[k]).isEmpty() {
+				r.vital = append(r.vital, k)
+				vital[k] = append(vital[k], len(regions))
+			}
+		}
+		regions = append(regions, r)
+	}
+
+	// drop the chains with less than two vital regions, and the
+	// regions next to a dropped chain, until nothing changes
+	dead := make([]bool, len(chains))
+	gone := make([]bool, len(regions))
+	for changed := true; changed; {
+		changed = false
+		for k := range chains {
+			n := 0
+			for _, r := range vital[k] {
+				if !gone[r] {
+					n++
+				}
+			}
+			if !dead[k] && n < 2 {
+				dead[k] = true
+				changed = true
+			}
+		}
+		for r, region := range regions {
+			if gone[r] {
+				continue
+			}
+			for _, k := range region.borders {
+				if dead[k] {
+					gone[r] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+	for k, c := range chains {
+		if !dead[k] {
+			alive = alive.or(c)
+		}
+	}
+	for r, region := range regions {
+		if !gone[r] && len(region.vital) > 0 {
+			area = area.or(region.points)
+		}
+	}
+	return
+}
+
+// seki finds the unsettled chains of both players sharing liberties,
+// with no other liberty than one point eyes, where every chain has an
+// eye or none has, so that neither player can fill the shared liberties
+// without being taken
+func (state *State) seki(lf *life) (seki bitboard) {
+	l := state.layout
+	settled := lf.settled()
+	var stones [2]bitboard
+	for p := range stones {
+		stones[p] = state.stones[p].andNot(settled)
+	}
+	shared := l.dilate(stones[PlayerBlack]).
+		and(l.dilate(stones[PlayerWhite])).
+		and(state.empty()).andNot(settled)
+	area := shared.or(stones[PlayerBlack]).or(stones[PlayerWhite])
+	for rest := shared; !rest.isEmpty(); {
+		group := l.flood(l.single(rest.first()), area)
+		rest = rest.andNot(group)
+		if points, ok := state.sekiGroup(group, shared, stones); ok {
+			seki = seki.or(points)
+		}
+	}
+	return
+}
+
+// sekiGroup checks the chains and shared liberties in group, and
+// returns them with the eyes when they are in seki
+func (state *State) sekiGroup(group, shared bitboard, stones [2]bitboard) (points bitboard, ok bool) {
+	l := state.layout
+	empty := state.empty()
+	points = group
+	var chains [2]int
+	eyed := 0
+	for _, player := range []Player{PlayerBlack, PlayerWhite} {
+		for rest := group.and(stones[player]); !rest.isEmpty(); {
+			c := l.flood(l.single(rest.first()), stones[player])
+			rest = rest.andNot(c)
+			chains[player]++
+			libs := l.around(c).and(empty)
+			if libs.count() < 2 {
+				return points, false
+			}
+			eyes := libs.andNot(shared)
+			for _, i := range eyes.points() {
+				if !l.around(l.single(i)).andNot(state.stones[player]).isEmpty() {
+					// a liberty out of the seki
+					return points, false
+				}
+			}
+			if !eyes.isEmpty() {
+				eyed++
+			}
+			points = points.or(eyes)
+		}
+	}
+	if chains[PlayerBlack] == 0 || chains[PlayerWhite] == 0 {
+		return points, false
+	}
+	if eyed != 0 && eyed != chains[PlayerBlack]+chains[PlayerWhite] {
+		return points, false
+	}
+	return points, true
+}
@@ -0,0 +1,140 @@
+package algo
+
+import "testing"
+
+// setupBoard puts the stones of rows, X for black and O for white,
+// row i is x = i and column j is y = j
+func setupBoard(rules Ruleset, rows ...string) *State {
+	st, err := NewRectState(len(rows[0]), len(rows), rules)
+	if err != nil {
+		panic(err)
+	}
+	for x, row := range rows {
+		for y, c := range row {
+			switch c {
+			case 'X':
+				st.put(st.layout.index(x, y), PlayerBlack)
+			case 'O':
+				st.put(st.layout.index(x, y), PlayerWhite)
+			}
+		}
+	}
+	return st
+}
+
+func checkLife(t *testing.T, st *State, expect ...string) {
+	marks := map[LifeStatus]byte{
+		LifeUnsettled: '.',
+		LifeBlack:     'B',
+		LifeWhite:     'W',
+		LifeSeki:      'S',
+	}
+	for x, row := range st.LifeMap() {
+		got := make([]byte, len(row))
+		for y, s := range row {
+			got[y] = marks[s]
+		}
+		if string(got) != expect[x] {
+			t.Errorf("row %d should be %s, but: %s\n%s", x, expect[x], got, st)
+		}
+	}
+}
+
+func Test_benson(t *testing.T) {
+	// black lives with the eyes at 0-0 and 0-2, the white stone
+	// at 0-3 is dead, white has no eye yet
+	st := setupBoard(RulesChinese,
+		".X.OX",
+		"XXXXX",
+		"OOOOO",
+		".....",
+		".....",
+	)
+	checkLife(t, st,
+		"BBBBB",
+		"BBBBB",
+		".....",
+		".....",
+		".....",
+	)
+	if st.Score() != -12.5 {
+		t.Error("dead stone should be taken off, score should be -12.5, but:", st.Score())
+	}
+	black, white := st.Area()
+	if black != 8 || white != 16 {
+		t.Error("area should still count the dead stone, but:", black, white)
+	}
+}
+
+func Test_seki(t *testing.T) {
+	// both sides have one eye and share the middle column
+	rows := []string{
+		".X.O.",
+		"XX.OO",
+		"XX.OO",
+		"XX.OO",
+		"XX.OO",
+	}
+	st := setupBoard(RulesChinese, rows...)
+	checkLife(t, st, "SSSSS", "SSSSS", "SSSSS", "SSSSS", "SSSSS")
+	if !st.hasResult() {
+		t.Error("a settled board should have a result")
+	}
+	if st.Score() != -7.5 {
+		t.Error("eyes in seki count under area scoring, but:", st.Score())
+	}
+	st = setupBoard(RulesJapanese, rows...)
+	if st.Score() != -6.5 {
+		t.Error("eyes in seki do not count under territory scoring, but:", st.Score())
+	}
+
+	// black has no eye, white lives with the middle column as
+	// the second vital region and black is dead
+	st = setupBoard(RulesChinese,
+		"XX.O.",
+		"XX.OO",
+		"XX.OO",
+		"XX.OO",
+		"XX.OO",
+	)
+	checkLife(t, st, "WWWWW", "WWWWW", "WWWWW", "WWWWW", "WWWWW")
+}
+
+func BenchmarkLife(b *testing.B) {
+	SetLogLevel(Error)
+	st := NewState(BoardSizeLarge, RulesChinese)
+	for _, action := range benchGame(BoardSizeLarge, 120) {
+		st = st.MoveTo(action)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		st.lifeCache = nil
+		st.life()
+	}
+}
+
+func Test_lifeCache(t *testing.T) {
+	st := setupBoard(RulesChinese,
+		".X.O.",
+		"XX.OO",
+		".....",
+		".....",
+		".....",
+	)
+	lf := st.life()
+	if st.life() != lf || st.clone().life() != lf {
+		t.Error("life should be found once for the same stones")
+	}
+	if err := st.Play(NewAction(4, 4, PlayerBlack)); err != nil {
+		t.Fatal(err)
+	}
+	if st.life() == lf {
+		t.Error("life should be found again after a move")
+	}
+	if err := st.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if st.life().hash != lf.hash {
+		t.Error("life should be of the stones back on board")
+	}
+}
@@ -2,6 +2,8 @@ package algo
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"time"
 )
 
@@ -34,18 +36,28 @@ const (
 
 type Log struct {
 	level LogLevel
+	out   io.Writer
 }
 
-var log *Log = &Log{level: Trace}
+var log *Log = &Log{level: Trace, out: os.Stdout}
 
 func SetLogLevel(level LogLevel) {
 	log.SetLogLevel(level)
 }
 
+// SetLogOutput sends the logs to w, stdout by default.
+func SetLogOutput(w io.Writer) {
+	log.SetLogOutput(w)
+}
+
 func (log *Log) SetLogLevel(level LogLevel) {
 	log.level = level
 }
 
+func (log *Log) SetLogOutput(w io.Writer) {
+	log.out = w
+}
+
 func (log *Log) Debug(msg ...interface{}) {
 	log.Log(Debug, msg...)
 }
@@ -70,7 +82,7 @@ func (log *Log) Log(level LogLevel, msg ...interface{}) {
 	if log.level > level {
 		return
 	}
-	fmt.Printf("%s[%s] %s\n", now(), LogPrefix[level], fmt.Sprint(msg...))
+	fmt.Fprintf(log.out, "%s[%s] %s\n", now(), LogPrefix[level], fmt.Sprint(msg...))
 }
 
 func (log *Log) Debugf(fmt string, msg ...interface{}) {
@@ -96,7 +108,7 @@ func (log *Log) Logf(level LogLevel, format string, msg ...interface{}) {
 	if log.level > level {
 		return
 	}
-	fmt.Printf("%s[%s] %s\n", now(), LogPrefix[level], fmt.Sprintf(format, msg...))
+	fmt.Fprintf(log.out, "%s[%s] %s\n", now(), LogPrefix[level], fmt.Sprintf(format, msg...))
 }
 
 func now() string {
@@ -3,36 +3,117 @@ package algo
 import (
 	"math"
 	"math/rand"
+	"sync/atomic"
 )
 
-// MCTS expend tree.
-func (root *TreeNode) MCTS() {
-	for !root.allRollout {
-		root.rollout()
-	}
+// Search is an MCTS running in the background.
+type Search struct {
+	stop int32
+	done chan struct{}
+}
+
+// MCTS expands the tree in the background until stopped or all
+// searched, the search is stoppable as soon as it is returned.
+func (root *TreeNode) MCTS() *Search {
+	root.trackOwnership()
+	s := &Search{done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		for !s.stopped() && !root.searched() {
+			root.rollout(s)
+		}
+	}()
+	return s
+}
+
+// Stop stops the search and waits for the rollout running to end.
+func (s *Search) Stop() {
+	atomic.StoreInt32(&s.stop, 1)
+	<-s.done
+}
+
+// Done is closed when the search ends.
+func (s *Search) Done() <-chan struct{} {
+	return s.done
+}
+
+// stopped tells the search was stopped, a nil search never is
+func (s *Search) stopped() bool {
+	return s != nil && atomic.LoadInt32(&s.stop) == 1
 }
 
-func (root *TreeNode) Stop() {
-	root.ctx.stop = true
+// searched tells every rollout from root has been run
+func (root *TreeNode) searched() bool {
+	root.lock()
+	defer root.unlock()
+	return root.allRollout
 }
 
-// BestMove get best move
+// BestMove get best move, or the resignation
+// when the resign threshold is reached.
 func (root *TreeNode) BestMove() *TreeNode {
-	return root.bestMove(1.4)
+	if root.shouldResign() {
+		return root.Resign()
+	}
+	return root.bestMove(uctExploration)
+}
+
+// SetResign makes BestMove resign once the win rate at the root stays
+// below threshold (0-1) for the given consecutive moves, 0 moves for never.
+func (root *TreeNode) SetResign(threshold float64, moves int) {
+	root.ctx.resignThreshold = threshold
+	root.ctx.resignMoves = moves
+	root.ctx.lowMoves = [2]int{}
 }
 
-func (root *TreeNode) rollout() Player {
+// Commit records that the player to move at root plays the move
+// searched, counting the moves in a row it was below the resign
+// threshold.
+func (root *TreeNode) Commit() {
+	player := root.state.nextMovePlayer
+	if root.lowWinRate() {
+		root.ctx.lowMoves[player]++
+	} else {
+		root.ctx.lowMoves[player] = 0
+	}
+}
+
+// Resign returns the node of the player to move resigning.
+func (root *TreeNode) Resign() *TreeNode {
+	return root.newChildFromAction(NewResignAction(root.state.nextMovePlayer))
+}
+
+func (root *TreeNode) shouldResign() bool {
+	ctx := root.ctx
+	if ctx.resignMoves <= 0 || root.state.IsGameOver() || !root.lowWinRate() {
+		return false
+	}
+	player := root.state.nextMovePlayer
+	log.Infof("win rate of %s: %.3f, low for %d moves before", player,
+		float64(root.GetWins())/float64(root.GetN()), ctx.lowMoves[player])
+	return ctx.lowMoves[player]+1 >= ctx.resignMoves
+}
+
+// lowWinRate tells the win rate at root is below the resign threshold
+func (root *TreeNode) lowWinRate() bool {
+	n := root.GetN()
+	return n > 0 && float64(root.GetWins())/float64(n) < root.ctx.resignThreshold
+}
+
+func (root *TreeNode) rollout(s *Search) Player {
 	log.Trace("rollout:", root)
-	if root.ctx.stop {
+	if s.stopped() {
 		log.Trace("rollout stop")
-		return -1
+		return PlayerNone
 	}
 	root.lock()
 	log.Trace("rollout get lock")
 	node := root.rolloutPolicy()
 	if root.state.hasResult() || node == nil {
-		result := root.state.Result()
+		result := root.state.quickResult()
+		black, white := root.state.areas()
 		root.backpropagate(result)
+		root.own(black, white)
 		root.allRollout = true
 		log.Infof("rollout a result %v %s:", result, root)
 		root.unlock()
@@ -41,18 +122,31 @@ func (root *TreeNode) rollout() Player {
 	}
 	root.unlock()
 	log.Trace("rollout unlock")
-	log.Tracef("rollout a node: %s with board %s", node, node.state.board)
-	return node.rollout()
+	log.Tracef("rollout a node: %s with board %s", node, node.state)
+	return node.rollout(s)
 }
 
 func (root *TreeNode) rolloutPolicy() *TreeNode {
 	// random select an move from all possible moves.
 	root.expand()
+	settled := root.state.life().settled()
 	list := []*TreeNode{}
+	rest := []*TreeNode{}
 	for _, node := range root.children {
-		if !node.allRollout {
-			list = append(list, node)
+		if node.allRollout {
+			continue
 		}
+		// a move inside a settled area cannot change the result,
+		// try it only when nothing else is left
+		a := node.action
+		if a.kind == actionMove && settled.has(root.state.layout.index(int(a.x), int(a.y))) {
+			rest = append(rest, node)
+			continue
+		}
+		list = append(list, node)
+	}
+	if len(list) == 0 {
+		list = rest
 	}
 	n := len(list)
 	if n == 0 {
@@ -64,12 +158,93 @@ func (root *TreeNode) rolloutPolicy() *TreeNode {
 
 func (root *TreeNode) backpropagate(result Player) {
 	root.visitTimes++
-	root.result[result]++
+	// a jigo is a visit won by nobody
+	if result != PlayerNone {
+		root.result[result]++
+	}
 	if root.parent != nil {
 		root.parent.backpropagate(result)
 	}
 }
 
+// own adds the areas at the end of a rollout to the ownership of
+// the nodes up from root keeping one
+func (root *TreeNode) own(black, white bitboard) {
+	for node := root; node != nil; node = node.parent {
+		if node.ownership == nil {
+			continue
+		}
+		node.owned++
+		for _, i := range black.points() {
+			node.ownership[i]++
+		}
+		for _, i := range white.points() {
+			node.ownership[i]--
+		}
+	}
+}
+
+// trackOwnership makes the rollouts from root add up its ownership
+func (root *TreeNode) trackOwnership() {
+	root.lock()
+	defer root.unlock()
+	if root.ownership == nil {
+		root.ownership = make([]int, len(root.state.chain))
+	}
+}
+
+// Ownership returns the mean owner of each point at the end of the
+// rollouts run from root, 1 for Black and -1 for White, indexed like
+// the board, nil before MCTS.
+func (root *TreeNode) Ownership() [][]float64 {
+	root.lock()
+	defer root.unlock()
+	return root.ownershipMap()
+}
+
+// ownershipMap is Ownership with the lock held
+func (root *TreeNode) ownershipMap() [][]float64 {
+	if root.owned == 0 {
+		return nil
+	}
+	l := root.state.layout
+	res := make([][]float64, l.height)
+	for x := range res {
+		res[x] = make([]float64, l.width)
+		for y := range res[x] {
+			res[x][y] = float64(root.ownership[l.index(x, y)]) / float64(root.owned)
+		}
+	}
+	return res
+}
+
+// EstimateScore returns Black's expected area minus White's and komi
+// by the ownership, 0 before MCTS.
+func (root *TreeNode) EstimateScore() float64 {
+	own := root.Ownership()
+	if own == nil {
+		return 0
+	}
+	sum := 0.0
+	for _, row := range own {
+		for _, v := range row {
+			sum += v
+		}
+	}
+	return sum - root.state.rules.Komi
+}
+
+// uctExploration is the weight BestMove gives to the moves visited less
+const uctExploration = 1.4
+
+// uctOf returns the UCT value of the child node with exploration c
+func (root *TreeNode) uctOf(node *TreeNode, c float64) float64 {
+	return float64(node.q()/node.n()) +
+		c*math.Sqrt(
+			(2*math.Log(float64(root.n()))/float64(node.n())),
+		)
+}
+
 func (root *TreeNode) bestMove(c float64) *TreeNode {
 	if len(root.children) == 0 {
 		return root.rolloutPolicy()
@@ -78,10 +253,7 @@ func (root *TreeNode) bestMove(c float64) *TreeNode {
 	var max float64
 
 	for i, node := range root.children {
-		node.uct = float64(node.q()/node.n()) +
-			c*math.Sqrt(
-				(2*math.Log(float64(root.n()))/float64(node.n())),
-			)
+		node.uct = root.uctOf(node, c)
 		if node.uct > max {
 			max = node.uct
 			index = i
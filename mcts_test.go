@@ -3,9 +3,104 @@ package algo
 import "testing"
 
 func TestMCTS(t *testing.T) {
-	root := NewTree("", BoardSizeMini)
+	root := NewTree("", BoardSizeMini, RulesChinese)
 	root.expand()
-	if len(root.children) != 25 {
-		t.Error("first avialable steps should be 25, but:", len(root.children))
+	// 6 points up to symmetry and a pass
+	if len(root.children) != 7 {
+		t.Error("first avialable steps should be 7, but:", len(root.children))
+	}
+	// a symmetric move is played by turning the child searched
+	corner := root.FindChild(0, 0)
+	corner.FindChild(1, 1).backpropagate(PlayerBlack)
+	node := root.FindChild(4, 4)
+	if node != corner || len(root.children) != 7 || node.GetN() != 1 {
+		t.Fatal("4-4 should take the child of 0-0, but:", node, len(root.children))
+	}
+	next := node.findChild(3, 3)
+	if next == nil || next.GetN() != 1 || next.state.at(4, 4) != BoardStatusBlack ||
+		next.state.at(3, 3) != BoardStatusWhite || next.state.at(0, 0) != BoardStatusEmpty {
+		t.Error("the moves under 4-4 should be turned, but:", next)
+	}
+	// below the root every point counts, 24 points and a pass
+	node = root.FindChild(2, 2).FindPass()
+	node.expand()
+	if len(node.children) != 25 {
+		t.Error("avialable steps after 2 moves should be 25, but:", len(node.children))
+	}
+}
+
+func TestMCTSPass(t *testing.T) {
+	root := NewTree("", BoardSizeMini, RulesChinese)
+	node := root.FindPass()
+	if node == nil || !node.GetAction().IsPass() {
+		t.Fatal("pass should be a child, but:", node)
+	}
+	if node.FindChild(0, 0) == nil {
+		t.Error("0-0 should not be taken by the pass")
+	}
+	node = node.FindPass()
+	if !node.GetState().IsGameOver() {
+		t.Error("two passes should end the game")
+	}
+	node.expand()
+	if len(node.children) != 0 {
+		t.Error("finished game should have no children, but:", len(node.children))
+	}
+}
+
+func TestResign(t *testing.T) {
+	root := NewTree("", BoardSizeMini, RulesChinese)
+	root.SetResign(0.2, 2)
+	root.visitTimes = 100
+	root.result = [2]int{10, 90}
+	if node := root.BestMove(); node.GetAction().IsResign() {
+		t.Error("should not resign at the first low move")
+	}
+	if node := root.BestMove(); node.GetAction().IsResign() {
+		t.Error("should not resign before the first low move is played")
+	}
+	root.Commit()
+	node := root.BestMove()
+	if !node.GetAction().IsResign() || node.GetState().Result() != PlayerWhite {
+		t.Error("black should resign at the second low move, but:", node)
+	}
+	root.result = [2]int{50, 50}
+	if node := root.BestMove(); node.GetAction().IsResign() {
+		t.Error("should not resign with a fair win rate")
+	}
+}
+
+func TestOwnership(t *testing.T) {
+	SetLogLevel(Error)
+	root, err := NewTreeFromState(setupBoard(RulesChinese,
+		".X.OX",
+		"XXXXX",
+		"OOOOO",
+		".....",
+		".....",
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root.Ownership() != nil {
+		t.Error("ownership should be nil before mcts")
+	}
+	root.trackOwnership()
+	for i := 0; i < 200; i++ {
+		root.rollout(nil)
+	}
+	own := root.Ownership()
+	if own == nil || own[0][0] != 1 || own[1][1] != 1 {
+		t.Fatal("black should own its eyes and stones, but:", own)
+	}
+	for x := range own {
+		for y := range own[x] {
+			if own[x][y] < -1 || own[x][y] > 1 {
+				t.Errorf("ownership of %d-%d should be in [-1,1], but: %g", x, y, own[x][y])
+			}
+		}
+	}
+	if score := root.EstimateScore(); score < -25 || score > 25 {
+		t.Error("estimated score should be on board, but:", score)
 	}
 }
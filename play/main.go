@@ -12,89 +12,202 @@ func main() {
 	fmt.Println("Welcome to our algo game!")
 	fmt.Println("First of all, we have to config some options.")
 	conf := config()
-	root := algo.NewTree("model", conf.Size)
+	root, err := algo.NewRectTree("model", conf.Width, conf.Height, conf.Rules)
+	if err != nil {
+		panic(err)
+	}
 	algo.SetLogLevel(algo.Info)
-	fmt.Println("\nLoading AI database...")
-	root.LoadCheckpoint()
+	root.SetResign(conf.ResignThreshold, resignMoves)
+	if conf.Handicap > 0 {
+		// the AI database only knows even games
+		if err := root.PlaceHandicap(conf.Handicap); err != nil {
+			panic(err)
+		}
+		fmt.Println("\nHandicap placed.")
+	} else {
+		fmt.Println("\nLoading AI database...")
+		root.LoadCheckpoint()
+	}
 	fmt.Println("Now, let's start, good luck!")
 	fmt.Println()
 
 	node := root
-	steps := 0
-	fmt.Sprintln(node.GetState().GetBoard())
-	for node != nil {
+	fmt.Println(node.GetState().GetBoard())
+	for node != nil && !node.GetState().IsGameOver() {
 		switch node.NextPlayer() {
 		case conf.UserPlayer:
 			fmt.Println("you turn:")
 			node = userMove(node)
 		default:
 			fmt.Println("AI turn:")
-			go node.MCTS()
+			search := node.MCTS()
 			<-time.After(conf.EachStepDuration)
-			node.Stop()
-			node = node.BestMove()
+			search.Stop()
+			printOwnership(node)
+			comment := ""
+			if n := node.GetN(); n > 0 {
+				comment = fmt.Sprintf(
+					"win rate %.3f, %d playouts, estimated score %.1f",
+					float64(node.GetWins())/float64(n), n, node.EstimateScore(),
+				)
+			}
+			best := node.BestMove()
+			if best != nil && !best.GetAction().IsResign() {
+				node.Commit()
+			}
+			node = best
+			if node != nil {
+				node.SetComment(comment)
+			}
 		}
 		if node != nil {
-			steps++
 			fmt.Println(
 				fmt.Sprintf(
-					"steps %d, %s",
-					steps,
+					"steps %d, %s, captures B %d W %d",
+					node.GetState().MoveNumber(),
 					node.GetAction(),
+					node.GetState().Captures(algo.PlayerBlack),
+					node.GetState().Captures(algo.PlayerWhite),
 				),
 				node.GetState().GetBoard())
 		}
 	}
+	if node != nil {
+		fmt.Printf(
+			"Winner: %s, %s\n",
+			node.GetState().Result(),
+			node.GetState().ResultString(),
+		)
+		info := algo.SGFInfo{Black: "algo", White: "algo"}
+		if conf.UserPlayer == algo.PlayerBlack {
+			info.Black = "human"
+		} else {
+			info.White = "human"
+		}
+		saveGame(node, info)
+	}
 	fmt.Println("Game over, be happy!")
 }
 
+// saveGame writes the game to an SGF file in the working directory
+func saveGame(node *algo.TreeNode, info algo.SGFInfo) {
+	now := time.Now()
+	info.Date = now.Format("2006-01-02")
+	file := now.Format("game-20060102-150405.sgf")
+	if err := node.SaveSGF(file, info); err != nil {
+		fmt.Printf("failed to save the game: %v\n", err)
+		return
+	}
+	fmt.Printf("Game saved to %s\n", file)
+}
+
+// printOwnership shows who the AI expects to own each point,
+// X and O when sure, x and o when likely
+func printOwnership(node *algo.TreeNode) {
+	own := node.Ownership()
+	if own == nil {
+		return
+	}
+	str := "\n   "
+	for y := range own[0] {
+		str += string(rune('a'+y)) + " "
+	}
+	for x := range own {
+		str += fmt.Sprintf("\n%2d ", x+1)
+		for _, v := range own[x] {
+			c := "."
+			switch {
+			case v > 0.6:
+				c = "X"
+			case v > 0.2:
+				c = "x"
+			case v < -0.6:
+				c = "O"
+			case v < -0.2:
+				c = "o"
+			}
+			str += c + " "
+		}
+	}
+	fmt.Printf("Ownership, estimated score %.1f:%s\n", node.EstimateScore(), str)
+}
+
 func userMove(root *algo.TreeNode) *algo.TreeNode {
-	fmt.Println("Please enter the point you will move, like a1")
+	fmt.Println("Please enter the point you will move, like a1, or pass, resign")
 	var op string
 	fmt.Scanln(&op)
+	switch op {
+	case "pass":
+		return root.FindPass()
+	case "resign":
+		return root.Resign()
+	}
 	if len(op) < 2 {
 		fmt.Printf("invalid move position: %s\n", op)
 		return userMove(root)
 	}
 	y := int(op[0] - 'a')
-	if y < 0 || y > root.GetState().GetBoard().Size() {
-		fmt.Printf("invalid move position: %s\n", op)
-		return userMove(root)
-	}
 	x, err := strconv.Atoi(op[1:])
 	x = x - 1
-	if err != nil || x < 0 || x > root.GetState().GetBoard().Size() {
+	if err != nil {
 		fmt.Printf("invalid move position: %s\n", op)
 		return userMove(root)
 	}
-	node := root.FindChild(x, y)
-	if node == nil {
-		fmt.Printf("invalid move position: %s\n", op)
+	if y < 0 || y >= root.GetState().Width() || x < 0 || x >= root.GetState().Height() {
+		fmt.Printf("invalid move %s: %v\n", op, algo.ErrOutOfBounds)
+		return userMove(root)
+	}
+	node, err := root.Play(algo.NewAction(x, y, root.NextPlayer()))
+	if err != nil {
+		fmt.Printf("invalid move %s: %v\n", op, err)
 		return userMove(root)
 	}
 	return node
 }
 
 type Config struct {
-	Size             algo.BoardSize
+	Width            int
+	Height           int
+	Rules            algo.Ruleset
+	Handicap         int
 	UserPlayer       algo.Player
 	EachStepDuration time.Duration
+	ResignThreshold  float64
 }
 
+// resignMoves is how many moves in a row the AI must be
+// below the resign threshold before it resigns
+const resignMoves = 3
+
 func config() *Config {
 	conf := &Config{}
-	conf.Size = selectSize()
+	conf.Width, conf.Height = selectSize()
+	conf.Rules = selectRules()
+	conf.Handicap = selectHandicap(conf.Width, conf.Height)
+	if conf.Handicap > 0 {
+		conf.Rules.Komi = conf.Rules.HandicapKomi(conf.Handicap)
+	}
+	conf.Rules.Komi = selectKomi(conf.Rules.Komi)
 	conf.UserPlayer = selectPlayer()
 	conf.EachStepDuration = selectDuration()
+	conf.ResignThreshold = selectResign()
 
 	fmt.Printf(
 		"Now, we will start the game with following configure:\n"+
 			"\tboard size: %d*%d\n"+
+			"\trules: %s\n"+
+			"\thandicap: %d\n"+
+			"\tkomi: %g\n"+
 			"\tyou are using: %s\n"+
-			"\tAI each step will take: %s\n\n",
-		conf.Size, conf.Size,
+			"\tAI each step will take: %s\n"+
+			"\tAI resigns below win rate: %g%%\n\n",
+		conf.Width, conf.Height,
+		conf.Rules.Name,
+		conf.Handicap,
+		conf.Rules.Komi,
 		conf.UserPlayer,
 		conf.EachStepDuration,
+		conf.ResignThreshold*100,
 	)
 	fmt.Printf("Press <Enter> for start, or input 'no' for reconfigure:")
 	var op string
@@ -105,23 +218,68 @@ func config() *Config {
 	return conf
 }
 
-func selectSize() algo.BoardSize {
+func selectSize() (width, height int) {
 	var op string
-	fmt.Println("Please enter board size:\n\t5(for dev)\t9\t13\t19")
+	fmt.Println("Please enter board size:\n\t5(for dev)\t9\t13\t19\tor 2-25, like 7x9 for width x height")
 	fmt.Scanln(&op)
-	switch op {
-	case "5":
-		return algo.BoardSizeMini
-	case "9":
-		return algo.BoardSizeSmall
-	case "13":
-		return algo.BoardSizeMedium
-	case "19":
-		return algo.BoardSizeLarge
-	default:
-		fmt.Printf("invalid size value: %s, please enter 5 or 9 or 13 or 19\n", op)
+	width, height, err := algo.ParseBoardSize(op)
+	if err != nil {
+		fmt.Printf("invalid size value: %s, %v\n", op, err)
 		return selectSize()
 	}
+	return width, height
+}
+
+func selectRules() algo.Ruleset {
+	var op string
+	fmt.Println("Please enter rules: (default chinese)")
+	for _, rules := range algo.Rulesets {
+		fmt.Printf("\t%s\n", rules.Name)
+	}
+	fmt.Scanln(&op)
+	if op == "" {
+		return algo.RulesChinese
+	}
+	rules, err := algo.RulesetByName(op)
+	if err != nil {
+		fmt.Printf("invalid rules value: %s\n", op)
+		return selectRules()
+	}
+	return rules
+}
+
+func selectHandicap(width, height int) int {
+	var op string
+	fmt.Println("Please enter handicap stones: (default 0, 2-9)")
+	fmt.Scanln(&op)
+	if op == "" || op == "0" {
+		return 0
+	}
+	n, err := strconv.Atoi(op)
+	if err == nil {
+		st, _ := algo.NewRectState(width, height, algo.RulesChinese)
+		err = st.PlaceHandicap(n)
+	}
+	if err != nil {
+		fmt.Printf("invalid handicap value: %s, %v\n", op, err)
+		return selectHandicap(width, height)
+	}
+	return n
+}
+
+func selectKomi(komi float64) float64 {
+	var op string
+	fmt.Printf("Please enter komi: (default %g, 0.5 is common for small board)\n", komi)
+	fmt.Scanln(&op)
+	if op == "" {
+		return komi
+	}
+	v, err := strconv.ParseFloat(op, 64)
+	if err != nil || v < -150 || v > 150 {
+		fmt.Printf("invalid komi value: %s, please enter a number like 6.5\n", op)
+		return selectKomi(komi)
+	}
+	return v
 }
 
 func selectPlayer() algo.Player {
@@ -150,3 +308,18 @@ func selectDuration() time.Duration {
 	}
 	return time.Duration(sec) * time.Second
 }
+
+func selectResign() float64 {
+	var op string
+	fmt.Println("Please enter the win rate AI resigns below: (Percent, 0-50, default 10, 0 for never)")
+	fmt.Scanln(&op)
+	if op == "" {
+		return 0.1
+	}
+	rate, err := strconv.Atoi(op)
+	if err != nil || rate < 0 || rate > 50 {
+		fmt.Printf("invalid win rate value: %s, please enter: 0-50\n", op)
+		return selectResign()
+	}
+	return float64(rate) / 100
+}
@@ -0,0 +1,152 @@
+package algo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// KoRule decides which repetitions are forbidden
+type KoRule int
+
+const (
+	// KoRuleSimple forbids retaking a single stone immediately
+	KoRuleSimple KoRule = iota
+	// KoRulePositional forbids recreating any earlier board position
+	KoRulePositional
+	// KoRuleSituational forbids recreating an earlier board position
+	// with the same player to move
+	KoRuleSituational
+)
+
+func (rule KoRule) String() string {
+	switch rule {
+	case KoRuleSimple:
+		return "simple"
+	case KoRulePositional:
+		return "positional"
+	case KoRuleSituational:
+		return "situational"
+	}
+	return "unknown"
+}
+
+// ScoringRule decides how the final score is counted
+type ScoringRule int
+
+const (
+	// ScoringArea counts stones and surrounded points
+	ScoringArea ScoringRule = iota
+	// ScoringTerritory counts surrounded points and prisoners
+	ScoringTerritory
+)
+
+func (rule ScoringRule) String() string {
+	switch rule {
+	case ScoringArea:
+		return "area"
+	case ScoringTerritory:
+		return "territory"
+	}
+	return "unknown"
+}
+
+// HandicapBonus decides the points White gets back in a handicap game
+type HandicapBonus int
+
+const (
+	// HandicapBonusNone gives nothing back
+	HandicapBonusNone HandicapBonus = iota
+	// HandicapBonusN gives a point for each handicap stone
+	HandicapBonusN
+	// HandicapBonusNMinusOne gives a point for each handicap stone but the first
+	HandicapBonusNMinusOne
+)
+
+// Ruleset is the rules a game is played under
+type Ruleset struct {
+	Name    string
+	Ko      KoRule
+	Scoring ScoringRule
+	// Suicide allows a move that leaves its own group of
+	// more than one stone without liberty, the group is removed
+	Suicide bool
+	// PassStone hands a prisoner to the opponent for each pass
+	PassStone bool
+	// CaptureDead counts every stone left on board at the end,
+	// dead stones have to be captured
+	CaptureDead bool
+	Komi        float64
+	// HandicapBonus is added to the komi of a handicap game
+	HandicapBonus HandicapBonus
+}
+
+var (
+	RulesChinese = Ruleset{
+		Name:          "chinese",
+		Ko:            KoRuleSimple,
+		Scoring:       ScoringArea,
+		Komi:          7.5,
+		HandicapBonus: HandicapBonusN,
+	}
+	RulesJapanese = Ruleset{
+		Name:    "japanese",
+		Ko:      KoRuleSimple,
+		Scoring: ScoringTerritory,
+		Komi:    6.5,
+	}
+	RulesAGA = Ruleset{
+		Name:          "aga",
+		Ko:            KoRuleSituational,
+		Scoring:       ScoringArea,
+		PassStone:     true,
+		Komi:          7.5,
+		HandicapBonus: HandicapBonusNMinusOne,
+	}
+	RulesTrompTaylor = Ruleset{
+		Name:        "tromp-taylor",
+		Ko:          KoRulePositional,
+		Scoring:     ScoringArea,
+		Suicide:     true,
+		CaptureDead: true,
+		Komi:        7.5,
+	}
+	RulesNewZealand = Ruleset{
+		Name:    "new-zealand",
+		Ko:      KoRuleSituational,
+		Scoring: ScoringArea,
+		Suicide: true,
+		Komi:    7,
+	}
+)
+
+// Rulesets lists the known rule sets
+var Rulesets = []Ruleset{
+	RulesChinese,
+	RulesJapanese,
+	RulesAGA,
+	RulesTrompTaylor,
+	RulesNewZealand,
+}
+
+// RulesetByName finds a known rule set, the name is case insensitive.
+func RulesetByName(name string) (Ruleset, error) {
+	for _, rules := range Rulesets {
+		if strings.EqualFold(rules.Name, name) {
+			return rules, nil
+		}
+	}
+	return Ruleset{}, fmt.Errorf("unknown rules: %s", name)
+}
+
+func (rules Ruleset) String() string {
+	return fmt.Sprintf(
+		"%s(ko:%s,scoring:%s,suicide:%t,pass stone:%t,capture dead:%t,komi:%g)",
+		rules.Name,
+		rules.Ko,
+		rules.Scoring,
+		rules.Suicide,
+		rules.PassStone,
+		rules.CaptureDead,
+		rules.Komi,
+	)
+}
@@ -0,0 +1,154 @@
+package algo
+
+import "fmt"
+
+// Result returns the winner by resignation or by FinalScore,
+// PlayerNone for a jigo.
+func (state *State) Result() Player {
+	return state.result(state.FinalScore)
+}
+
+// quickResult is the Result by Score, cheap enough for the rollouts
+func (state *State) quickResult() Player {
+	return state.result(state.Score)
+}
+
+func (state *State) result(score func() float64) Player {
+	if state.resigned {
+		return state.nextMovePlayer
+	}
+	switch score := score(); {
+	case score > 0:
+		return PlayerBlack
+	case score < 0:
+		return PlayerWhite
+	}
+	return PlayerNone
+}
+
+// ResultString formats the result like B+R, B+3.5, W+0.5 or 0 for jigo.
+func (state *State) ResultString() string {
+	if state.resigned {
+		return fmt.Sprintf("%c+R", state.nextMovePlayer.String()[0])
+	}
+	score := state.FinalScore()
+	switch {
+	case score > 0:
+		return fmt.Sprintf("B+%g", score)
+	case score < 0:
+		return fmt.Sprintf("W+%g", -score)
+	}
+	return "0"
+}
+
+// Score returns Black's points minus White's points and komi,
+// counted by the scoring rule, positive means Black wins.
+// Stones inside the pass-alive area of the opponent are taken off
+// first, and under territory scoring points in seki count for nobody.
+func (state *State) Score() float64 {
+	lf := state.life()
+	var dead [2]bitboard
+	for p := range dead {
+		dead[p] = state.stones[p].and(lf.area[Player(p).next()])
+	}
+	return state.score(lf, dead)
+}
+
+// finalScore is the FinalScore of the stones, prisoners and komi
+type finalScore struct {
+	hash      uint64
+	prisoners [2]int
+	komi      float64
+	score     float64
+}
+
+// FinalScore is the Score with the groups estimated dead taken off,
+// as prisoners under territory scoring. The playouts estimating the
+// dead groups run once for a position.
+func (state *State) FinalScore() float64 {
+	if c := state.scoreCache; c != nil && c.hash == state.hash &&
+		c.prisoners == state.prisoners && c.komi == state.rules.Komi {
+		return c.score
+	}
+	lf := state.life()
+	score := state.score(lf, state.deadStones(lf))
+	state.scoreCache = &finalScore{
+		hash:      state.hash,
+		prisoners: state.prisoners,
+		komi:      state.rules.Komi,
+		score:     score,
+	}
+	return score
+}
+
+// score counts the points with the dead stones of each player taken off,
+// unless the rules want them captured
+func (state *State) score(lf *life, dead [2]bitboard) float64 {
+	if state.rules.CaptureDead {
+		dead = [2]bitboard{}
+	}
+	ns := *state
+	for p := range ns.stones {
+		ns.stones[p] = ns.stones[p].andNot(dead[p])
+	}
+	var black, white int
+	switch state.rules.Scoring {
+	case ScoringTerritory:
+		black, white = ns.territory(lf.seki)
+		black += state.prisoners[PlayerBlack] + dead[PlayerWhite].count()
+		white += state.prisoners[PlayerWhite] + dead[PlayerBlack].count()
+	default:
+		black, white = ns.Area()
+	}
+	return float64(black-white) - state.rules.Komi
+}
+
+// Area counts the Tromp-Taylor area of each player: the stones,
+// and the empty points reaching only stones of that player.
+func (state *State) Area() (black, white int) {
+	black, white = state.territory(bitboard{})
+	black += state.stones[PlayerBlack].count()
+	white += state.stones[PlayerWhite].count()
+	return
+}
+
+// territory counts the empty points reaching only stones of one player,
+// but the skipped ones
+func (state *State) territory(skip bitboard) (black, white int) {
+	for _, r := range state.regions() {
+		switch {
+		case r.borders[PlayerBlack] && !r.borders[PlayerWhite]:
+			black += r.points.andNot(skip).count()
+		case r.borders[PlayerWhite] && !r.borders[PlayerBlack]:
+			white += r.points.andNot(skip).count()
+		}
+	}
+	return
+}
+
+// region is a connected set of empty points
+type region struct {
+	points bitboard
+	// borders tells whether stones of each player touch the region
+	borders [2]bool
+}
+
+// regions splits the empty points into regions, visiting each point once
+func (state *State) regions() []*region {
+	l := state.layout
+	var regions []*region
+	empty := state.empty()
+	for !empty.isEmpty() {
+		points := l.flood(l.single(empty.first()), empty)
+		empty = empty.andNot(points)
+		around := l.around(points)
+		regions = append(regions, &region{
+			points: points,
+			borders: [2]bool{
+				!around.and(state.stones[PlayerBlack]).isEmpty(),
+				!around.and(state.stones[PlayerWhite]).isEmpty(),
+			},
+		})
+	}
+	return regions
+}
@@ -0,0 +1,47 @@
+package algo
+
+import "testing"
+
+func Test_score(t *testing.T) {
+	// black owns the first two rows of a 5x5 board, white the other three
+	moves := [][]int{{1, 0}, {2, 0}, {1, 1}, {2, 1}, {1, 2}, {2, 2}, {1, 3}, {2, 3}, {1, 4}, {2, 4}}
+	for _, c := range []struct {
+		komi   float64
+		score  float64
+		str    string
+		winner Player
+	}{
+		{0.5, -5.5, "W+5.5", PlayerWhite},
+		{-5, 0, "0", PlayerNone},
+		{-7.5, 2.5, "B+2.5", PlayerBlack},
+	} {
+		rules := RulesChinese
+		rules.Komi = c.komi
+		st := playMoves(NewState(BoardSizeMini, rules), moves)
+		if st.Score() != c.score {
+			t.Error("komi", c.komi, "score should be", c.score, "but:", st.Score())
+		}
+		if st.ResultString() != c.str || st.Result() != c.winner {
+			t.Error("komi", c.komi, "result should be", c.str, c.winner, "but:", st.ResultString(), st.Result())
+		}
+	}
+	st := playMoves(NewState(BoardSizeMini, RulesJapanese), moves)
+	if st.Score() != -11.5 {
+		t.Error("territory score should be -11.5, but:", st.Score())
+	}
+}
+
+func Test_area(t *testing.T) {
+	// black row 1 and white row 3 leave row 2 as dame,
+	// the white stone in black's area is counted as alive
+	st := playMoves(NewState(BoardSizeMini, RulesTrompTaylor), [][]int{
+		{1, 0}, {3, 0}, {1, 1}, {3, 1}, {1, 2}, {3, 2}, {1, 3}, {3, 3}, {1, 4}, {3, 4}, nil, {0, 0},
+	})
+	black, white := st.Area()
+	if black != 5 || white != 11 {
+		t.Error("area should be 5-11, but:", black, white, st)
+	}
+	if st.Result() != PlayerWhite || st.ResultString() != "W+13.5" {
+		t.Error("white should win by 13.5, but:", st.ResultString())
+	}
+}
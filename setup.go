@@ -0,0 +1,94 @@
+package algo
+
+import "fmt"
+
+// AddStones puts stones of player on the empty points, the position
+// must keep a liberty for every chain. The setup position starts the
+// game record again.
+func (state *State) AddStones(player Player, points [][2]int) error {
+	ns := state.clone()
+	for _, p := range points {
+		i, err := ns.setupPoint(p)
+		if err != nil {
+			return err
+		}
+		if ns.occupied().has(i) {
+			return fmt.Errorf("%w: %d-%d", ErrOccupied, p[0], p[1])
+		}
+		ns.put(i, player)
+	}
+	return state.setup(ns)
+}
+
+// RemoveStones takes the stones off the points, which must have one.
+func (state *State) RemoveStones(points [][2]int) error {
+	ns := state.clone()
+	var affected []int
+	for _, p := range points {
+		i, err := ns.setupPoint(p)
+		if err != nil {
+			return err
+		}
+		if !ns.occupied().has(i) {
+			return fmt.Errorf("no stone to remove at: %d-%d", p[0], p[1])
+		}
+		for _, player := range []Player{PlayerBlack, PlayerWhite} {
+			if ns.stones[player].has(i) {
+				ns.stones[player].clear(i)
+				ns.hash ^= ns.layout.zobrist[i][player]
+			}
+		}
+		n := ns.layout.neighbors(i)
+		affected = append(affected, n[:]...)
+	}
+	ns.relink(affected)
+	return state.setup(ns)
+}
+
+// SetNextPlayer sets the player to move.
+func (state *State) SetNextPlayer(player Player) error {
+	ns := state.clone()
+	ns.nextMovePlayer = player
+	return state.setup(ns)
+}
+
+// Validate checks that every chain on board has a liberty.
+func (state *State) Validate() error {
+	for _, i := range state.occupied().points() {
+		if state.root(i) == i && state.chain[i].libs == 0 {
+			x, y := state.layout.point(i)
+			return fmt.Errorf("chain at %d-%d has no liberty", x, y)
+		}
+	}
+	return nil
+}
+
+func (state *State) setupPoint(p [2]int) (int, error) {
+	l := state.layout
+	if p[0] < 0 || p[0] >= l.height || p[1] < 0 || p[1] >= l.width {
+		return 0, fmt.Errorf("%w: %d-%d", ErrOutOfBounds, p[0], p[1])
+	}
+	return l.index(p[0], p[1]), nil
+}
+
+// setup takes the position of ns when it is valid, as the start of
+// a new game record
+func (state *State) setup(ns *State) error {
+	if err := ns.Validate(); err != nil {
+		return err
+	}
+	ns.ko = -1
+	ns.passes = 0
+	ns.resigned = false
+	ns.history = &position{hash: ns.hash, player: ns.nextMovePlayer}
+	*state = *ns
+	return nil
+}
+
+// clone copies the state, the undo records stay with the original
+func (state *State) clone() *State {
+	ns := *state
+	ns.chain = append([]chainPoint(nil), state.chain...)
+	ns.undo = nil
+	return &ns
+}
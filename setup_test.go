@@ -0,0 +1,75 @@
+package algo
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_setup(t *testing.T) {
+	st := NewState(BoardSizeMini, RulesChinese)
+	if err := st.AddStones(PlayerBlack, [][2]int{{0, 1}, {1, 0}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.AddStones(PlayerWhite, [][2]int{{0, 0}}); err == nil {
+		t.Error("white 0-0 should have no liberty")
+	}
+	if st.at(0, 0) != BoardStatusEmpty {
+		t.Error("a failed setup should change nothing, but:", st)
+	}
+	if err := st.AddStones(PlayerWhite, [][2]int{{0, 1}}); !errors.Is(err, ErrOccupied) {
+		t.Error("0-1 should be occupied, but:", err)
+	}
+	if err := st.AddStones(PlayerWhite, [][2]int{{5, 1}}); !errors.Is(err, ErrOutOfBounds) {
+		t.Error("5-1 should be out of board, but:", err)
+	}
+	if err := st.AddStones(PlayerWhite, [][2]int{{1, 1}, {0, 2}, {2, 0}}); err != nil {
+		t.Fatal(err)
+	}
+	checkChains(t, st)
+	if err := st.RemoveStones([][2]int{{3, 3}}); err == nil {
+		t.Error("3-3 has no stone to remove")
+	}
+	if err := st.RemoveStones([][2]int{{1, 1}, {0, 2}}); err != nil {
+		t.Fatal(err)
+	}
+	checkChains(t, st)
+	if err := st.SetNextPlayer(PlayerWhite); err != nil {
+		t.Fatal(err)
+	}
+	e := NewState(BoardSizeMini, RulesChinese)
+	e.put(e.layout.index(0, 1), PlayerBlack)
+	e.put(e.layout.index(1, 0), PlayerBlack)
+	e.put(e.layout.index(2, 0), PlayerWhite)
+	if st.stones != e.stones || st.hash != e.hash {
+		t.Error("position should be", e, "but:", st)
+	}
+	if st.NextPlayer() != PlayerWhite || st.MoveNumber() != 0 {
+		t.Error("white should start a new record, but:", st.NextPlayer(), st.MoveNumber())
+	}
+	// white takes nothing at 0-0, suicide
+	if err := st.Play(NewAction(0, 0, PlayerWhite)); !errors.Is(err, ErrSuicide) {
+		t.Error("0-0 should be suicide, but:", err)
+	}
+}
+
+func Test_newTreeFromState(t *testing.T) {
+	st := setupBoard(RulesChinese, "XO...", "O....", ".....", ".....", ".....")
+	if _, err := NewTreeFromState(st); err == nil {
+		t.Error("a chain without liberty should be rejected")
+	}
+	st = NewState(BoardSizeMini, RulesChinese)
+	_ = st.AddStones(PlayerBlack, [][2]int{{0, 1}})
+	_ = st.SetNextPlayer(PlayerWhite)
+	root, err := NewTreeFromState(st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root.expand()
+	if len(root.children) != 25 || root.NextPlayer() != PlayerWhite {
+		t.Error("white should have 24 points and a pass, but:", len(root.children))
+	}
+	_ = st.AddStones(PlayerBlack, [][2]int{{0, 0}})
+	if root.GetState().at(0, 0) != BoardStatusEmpty {
+		t.Error("tree should keep its own copy of the state")
+	}
+}
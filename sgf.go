@@ -0,0 +1,670 @@
+package algo
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SGFNode is a node of an SGF game tree, the first child is the
+// main line and the others are variations
+type SGFNode struct {
+	Props    map[string][]string
+	Children []*SGFNode
+}
+
+// Prop returns the first value of the property, empty when missing.
+func (node *SGFNode) Prop(name string) string {
+	if values := node.Props[name]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// ParseSGF reads the first game tree of an SGF (FF[4]) collection.
+func ParseSGF(data string) (*SGFNode, error) {
+	p := &sgfParser{data: data}
+	p.skipSpace()
+	if !p.eat('(') {
+		return nil, p.errorf("game tree should start with (")
+	}
+	return p.gameTree()
+}
+
+type sgfParser struct {
+	data string
+	pos  int
+}
+
+func (p *sgfParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("sgf at %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *sgfParser) skipSpace() {
+	for p.pos < len(p.data) && strings.ContainsRune(" \t\r\n", rune(p.data[p.pos])) {
+		p.pos++
+	}
+}
+
+// eat takes c when it is the next character
+func (p *sgfParser) eat(c byte) bool {
+	if p.pos < len(p.data) && p.data[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// gameTree reads the nodes of a sequence and the game trees after it,
+// the opening ( is already taken
+func (p *sgfParser) gameTree() (*SGFNode, error) {
+	var first, last *SGFNode
+	for {
+		p.skipSpace()
+		if !p.eat(';') {
+			break
+		}
+		node, err := p.node()
+		if err != nil {
+			return nil, err
+		}
+		if first == nil {
+			first = node
+		} else {
+			last.Children = append(last.Children, node)
+		}
+		last = node
+	}
+	if first == nil {
+		return nil, p.errorf("game tree should have a node")
+	}
+	for {
+		p.skipSpace()
+		switch {
+		case p.eat('('):
+			child, err := p.gameTree()
+			if err != nil {
+				return nil, err
+			}
+			last.Children = append(last.Children, child)
+		case p.eat(')'):
+			return first, nil
+		default:
+			return nil, p.errorf("game tree should end with )")
+		}
+	}
+}
+
+// node reads the properties of a node, the ; is already taken
+func (p *sgfParser) node() (*SGFNode, error) {
+	node := &SGFNode{Props: map[string][]string{}}
+	for {
+		p.skipSpace()
+		start := p.pos
+		for p.pos < len(p.data) && isLetter(p.data[p.pos]) {
+			p.pos++
+		}
+		if start == p.pos {
+			return node, nil
+		}
+		// old files spell properties like AddBlack, only the
+		// upper case letters count
+		var ident []byte
+		for i := start; i < p.pos; i++ {
+			if c := p.data[i]; c >= 'A' && c <= 'Z' {
+				ident = append(ident, c)
+			}
+		}
+		name := string(ident)
+		p.skipSpace()
+		if p.pos >= len(p.data) || p.data[p.pos] != '[' {
+			return nil, p.errorf("property %s should have a value", name)
+		}
+		for {
+			p.skipSpace()
+			if !p.eat('[') {
+				break
+			}
+			value, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			node.Props[name] = append(node.Props[name], value)
+		}
+	}
+}
+
+// value reads a property value up to the closing ], the [ is already taken
+func (p *sgfParser) value() (string, error) {
+	var b strings.Builder
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		p.pos++
+		switch c {
+		case ']':
+			return b.String(), nil
+		case '\\':
+			if p.pos >= len(p.data) {
+				continue
+			}
+			next := p.data[p.pos]
+			p.pos++
+			// a soft line break is dropped
+			if next == '\n' || next == '\r' {
+				if p.pos < len(p.data) && p.data[p.pos] != next &&
+					(p.data[p.pos] == '\n' || p.data[p.pos] == '\r') {
+					p.pos++
+				}
+				continue
+			}
+			b.WriteByte(next)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", p.errorf("value should end with ]")
+}
+
+func isLetter(c byte) bool {
+	return c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z'
+}
+
+// sgfRules maps the RU values of SGF files to the rule sets
+var sgfRules = map[string]Ruleset{
+	"chinese":      RulesChinese,
+	"japanese":     RulesJapanese,
+	"aga":          RulesAGA,
+	"nz":           RulesNewZealand,
+	"new zealand":  RulesNewZealand,
+	"tromp-taylor": RulesTrompTaylor,
+	"tromp taylor": RulesTrompTaylor,
+}
+
+// sgfRuleNames maps the rule sets to the RU values of SGF files
+var sgfRuleNames = map[string]string{
+	RulesChinese.Name:     "Chinese",
+	RulesJapanese.Name:    "Japanese",
+	RulesAGA.Name:         "AGA",
+	RulesNewZealand.Name:  "NZ",
+	RulesTrompTaylor.Name: "Tromp-Taylor",
+}
+
+// newState makes the state of the root node: the board size, rules,
+// komi and handicap
+func (node *SGFNode) newState() (*State, error) {
+	width, height := 19, 19
+	if sz := node.Prop("SZ"); sz != "" {
+		var err error
+		width, height, err = ParseBoardSize(strings.Replace(sz, ":", "x", 1))
+		if err != nil {
+			return nil, err
+		}
+	}
+	rules := RulesChinese
+	if ru := strings.ToLower(strings.TrimSpace(node.Prop("RU"))); ru != "" {
+		if r, ok := sgfRules[ru]; ok {
+			rules = r
+		} else if r, err := RulesetByName(ru); err == nil {
+			rules = r
+		}
+	}
+	state, err := NewRectState(width, height, rules)
+	if err != nil {
+		return nil, err
+	}
+	if ha := node.Prop("HA"); ha != "" {
+		n, err := strconv.Atoi(strings.TrimSpace(ha))
+		if err != nil {
+			return nil, fmt.Errorf("invalid handicap: %s", ha)
+		}
+		if n >= 2 {
+			err = state.placeSGFHandicap(n, node.Props["AB"])
+			if err != nil {
+				return nil, err
+			}
+			state.rules.Komi = rules.HandicapKomi(n)
+		}
+	}
+	if km := node.Prop("KM"); km != "" {
+		komi, err := strconv.ParseFloat(strings.TrimSpace(km), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid komi: %s", km)
+		}
+		state.rules.Komi = komi
+	}
+	return state, nil
+}
+
+// placeSGFHandicap puts the handicap stones given by AB, or on the
+// star points when there is none
+func (state *State) placeSGFHandicap(n int, stones []string) error {
+	if len(stones) == 0 {
+		return state.PlaceHandicap(n)
+	}
+	points, err := state.sgfPoints(stones)
+	if err != nil {
+		return err
+	}
+	return state.PlaceFreeHandicap(points)
+}
+
+// setupSGF makes the setup properties of node on state, AB is left
+// out at the root of a handicap game as it has been placed
+func (state *State) setupSGF(node *SGFNode, handicap bool) error {
+	for _, prop := range []struct {
+		name   string
+		player Player
+	}{{"AB", PlayerBlack}, {"AW", PlayerWhite}} {
+		if len(node.Props[prop.name]) == 0 || prop.player == PlayerBlack && handicap {
+			continue
+		}
+		points, err := state.sgfPoints(node.Props[prop.name])
+		if err != nil {
+			return err
+		}
+		if err := state.AddStones(prop.player, points); err != nil {
+			return err
+		}
+	}
+	if ae := node.Props["AE"]; len(ae) > 0 {
+		points, err := state.sgfPoints(ae)
+		if err != nil {
+			return err
+		}
+		if err := state.RemoveStones(points); err != nil {
+			return err
+		}
+	}
+	switch strings.ToUpper(node.Prop("PL")) {
+	case "B":
+		return state.SetNextPlayer(PlayerBlack)
+	case "W":
+		return state.SetNextPlayer(PlayerWhite)
+	}
+	return nil
+}
+
+// hasSetup tells whether node changes the board other than by a move
+func (node *SGFNode) hasSetup() bool {
+	for _, name := range []string{"AB", "AW", "AE", "PL"} {
+		if len(node.Props[name]) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// sgfAction returns the move of node, nil for a node without one
+func (state *State) sgfAction(node *SGFNode) (*Action, error) {
+	for _, prop := range []struct {
+		name   string
+		player Player
+	}{{"B", PlayerBlack}, {"W", PlayerWhite}} {
+		values, ok := node.Props[prop.name]
+		if !ok {
+			continue
+		}
+		v := ""
+		if len(values) > 0 {
+			v = strings.TrimSpace(values[0])
+		}
+		l := state.layout
+		// tt is a pass on boards up to 19
+		if v == "" || v == "tt" && l.width <= 19 && l.height <= 19 {
+			return NewPassAction(prop.player), nil
+		}
+		x, y, err := state.sgfPoint(v)
+		if err != nil {
+			return nil, err
+		}
+		return NewAction(x, y, prop.player), nil
+	}
+	return nil, nil
+}
+
+// adoptPlayer hands the move to the player of action when the game
+// record starts here, as SGF files often leave PL out after a setup
+func (state *State) adoptPlayer(action *Action) {
+	if state.history.prev == nil && action.player != state.nextMovePlayer {
+		state.SetNextPlayer(action.player)
+	}
+}
+
+// sgfPoint reads a point like cd, the column c and the row d
+func (state *State) sgfPoint(v string) (x, y int, err error) {
+	if len(v) != 2 {
+		return 0, 0, fmt.Errorf("invalid sgf point: %s", v)
+	}
+	y, x = int(v[0])-'a', int(v[1])-'a'
+	if x < 0 || x >= state.layout.height || y < 0 || y >= state.layout.width {
+		return 0, 0, fmt.Errorf("%w: %s", ErrOutOfBounds, v)
+	}
+	return x, y, nil
+}
+
+// sgfPoints reads a list of points, each may be a rectangle like aa:cc
+func (state *State) sgfPoints(values []string) ([][2]int, error) {
+	var points [][2]int
+	for _, v := range values {
+		parts := strings.SplitN(strings.TrimSpace(v), ":", 2)
+		x1, y1, err := state.sgfPoint(parts[0])
+		if err != nil {
+			return nil, err
+		}
+		x2, y2 := x1, y1
+		if len(parts) == 2 {
+			if x2, y2, err = state.sgfPoint(parts[1]); err != nil {
+				return nil, err
+			}
+		}
+		for x := x1; x <= x2; x++ {
+			for y := y1; y <= y2; y++ {
+				points = append(points, [2]int{x, y})
+			}
+		}
+	}
+	return points, nil
+}
+
+// State returns the state at the end of the main line, following the
+// first child of each node.
+func (node *SGFNode) State() (*State, error) {
+	state, err := node.newState()
+	if err != nil {
+		return nil, err
+	}
+	handicap := state.handicap > 0
+	for n, move := node, 0; n != nil; handicap = false {
+		if err := state.setupSGF(n, handicap); err != nil {
+			return nil, err
+		}
+		action, err := state.sgfAction(n)
+		if err != nil {
+			return nil, err
+		}
+		if action != nil {
+			move++
+			state.adoptPlayer(action)
+			if err := state.Play(action); err != nil {
+				return nil, fmt.Errorf("move %d: %w", move, err)
+			}
+		}
+		if len(n.Children) == 0 {
+			break
+		}
+		n = n.Children[0]
+	}
+	return state, nil
+}
+
+// Tree returns a tree with all the variations, and the comments on
+// the nodes. Setup properties are only taken at the root.
+func (node *SGFNode) Tree() (*TreeNode, error) {
+	state, err := node.newState()
+	if err != nil {
+		return nil, err
+	}
+	if err := state.setupSGF(node, state.handicap > 0); err != nil {
+		return nil, err
+	}
+	for n := node; n != nil; n = n.Children[0] {
+		if action, err := state.sgfAction(n); err == nil && action != nil {
+			state.adoptPlayer(action)
+			break
+		}
+		if len(n.Children) == 0 {
+			break
+		}
+	}
+	root, err := NewTreeFromState(state)
+	if err != nil {
+		return nil, err
+	}
+	root.comment = node.Prop("C")
+	if err := root.addSGF(node); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// addSGF plays the move of node, if any, from root and then its
+// children from the node reached. Only the children of the moves read
+// are made, and symmetric variations are kept apart, expand adds the
+// other moves when searched.
+func (root *TreeNode) addSGF(node *SGFNode) error {
+	action, err := root.state.sgfAction(node)
+	if err != nil {
+		return err
+	}
+	if action != nil {
+		if err := root.state.CheckAction(action); err != nil {
+			return fmt.Errorf("move %d: %w", root.state.MoveNumber()+1, err)
+		}
+		child := root.findAction(action)
+		if child == nil {
+			child = root.newChildFromAction(action)
+			root.children = append(root.children, child)
+			root.updateTotal(1)
+		}
+		root = child
+		if c := node.Prop("C"); c != "" {
+			root.comment = c
+		}
+	}
+	for _, child := range node.Children {
+		if child.hasSetup() {
+			return fmt.Errorf("setup after the root is not supported")
+		}
+		if err := root.addSGF(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SGFInfo is the game information written to SGF files
+type SGFInfo struct {
+	Black string
+	White string
+	// Date is like 2006-01-02
+	Date string
+}
+
+// SaveSGF writes the game from the root of the tree to node into file.
+func (node *TreeNode) SaveSGF(file string, info SGFInfo) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if err := node.WriteSGF(f, info); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// WriteSGF writes the game from the root of the tree to node, with the
+// comments of the nodes and the result when the game is over. The
+// position of the root is written as setup stones.
+func (node *TreeNode) WriteSGF(w io.Writer, info SGFInfo) error {
+	var b strings.Builder
+	node.writeSGFGame(&b, info, (*TreeNode).Comment)
+	b.WriteString(")\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteSGFTree writes the game up to node followed by the subtree
+// searched from it, each child a variation with the most visited
+// first. Children with fewer than minVisits visits are left out, and
+// so are the nodes deeper than maxDepth below node, 0 for no limit.
+// The search statistics are put in the comments.
+func (node *TreeNode) WriteSGFTree(w io.Writer, minVisits, maxDepth int) error {
+	if maxDepth <= 0 {
+		maxDepth = -1
+	}
+	var b strings.Builder
+	node.lock()
+	node.writeSGFGame(&b, SGFInfo{}, (*TreeNode).sgfAnnotation)
+	node.writeSGFVariations(&b, minVisits, maxDepth)
+	node.unlock()
+	b.WriteString(")\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// SaveSGFTree writes the subtree searched from node into file, see
+// WriteSGFTree.
+func (node *TreeNode) SaveSGFTree(file string, minVisits, maxDepth int) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if err := node.WriteSGFTree(f, minVisits, maxDepth); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeSGFGame writes the root properties and the moves from the root
+// of the tree to node, leaving the game tree open
+func (node *TreeNode) writeSGFGame(b *strings.Builder, info SGFInfo, comment func(*TreeNode) string) {
+	var path []*TreeNode
+	for n := node; n.parent != nil; n = n.parent {
+		path = append(path, n)
+	}
+	head := node.head()
+	b.WriteString("(;FF[4]GM[1]CA[UTF-8]AP[algo]")
+	head.state.writeSGFRoot(b)
+	writeSGFProp(b, "PB", info.Black)
+	writeSGFProp(b, "PW", info.White)
+	writeSGFProp(b, "DT", info.Date)
+	if node.state.IsGameOver() {
+		writeSGFProp(b, "RE", node.state.ResultString())
+	}
+	writeSGFProp(b, "C", comment(head))
+	for i := len(path) - 1; i >= 0; i-- {
+		n := path[i]
+		// a resignation is told by the result
+		if n.action.IsResign() {
+			continue
+		}
+		b.WriteString("\n;")
+		writeSGFAction(b, n.action)
+		writeSGFProp(b, "C", comment(n))
+	}
+}
+
+// writeSGFVariations writes the children of node kept by minVisits,
+// down to depth levels, -1 for all
+func (node *TreeNode) writeSGFVariations(b *strings.Builder, minVisits, depth int) {
+	if depth == 0 {
+		return
+	}
+	var children []*TreeNode
+	for _, child := range node.children {
+		if child.visitTimes >= minVisits {
+			children = append(children, child)
+		}
+	}
+	sort.SliceStable(children, func(i, j int) bool {
+		return children[i].visitTimes > children[j].visitTimes
+	})
+	for _, child := range children {
+		// a single child goes on in the same sequence
+		if len(children) > 1 {
+			b.WriteString("\n(;")
+		} else {
+			b.WriteString("\n;")
+		}
+		writeSGFAction(b, child.action)
+		writeSGFProp(b, "C", child.sgfAnnotation())
+		child.writeSGFVariations(b, minVisits, depth-1)
+		if len(children) > 1 {
+			b.WriteString(")")
+		}
+	}
+}
+
+// sgfAnnotation returns the comment of node followed by its search
+// statistics, the uct as BestMove finds it from the parent, 0 at the root
+func (node *TreeNode) sgfAnnotation() string {
+	var uct float64
+	if node.parent != nil {
+		uct = node.parent.uctOf(node, uctExploration)
+	}
+	stats := fmt.Sprintf(
+		"visits %d, wins B %d W %d, uct %.3f",
+		node.visitTimes, node.result[PlayerBlack], node.result[PlayerWhite], uct,
+	)
+	if node.comment == "" {
+		return stats
+	}
+	return node.comment + "\n" + stats
+}
+
+// writeSGFRoot writes the board size, rules, komi and the stones
+func (state *State) writeSGFRoot(b *strings.Builder) {
+	l := state.layout
+	if l.width == l.height {
+		fmt.Fprintf(b, "SZ[%d]", l.width)
+	} else {
+		fmt.Fprintf(b, "SZ[%d:%d]", l.width, l.height)
+	}
+	ru, ok := sgfRuleNames[state.rules.Name]
+	if !ok {
+		ru = state.rules.Name
+	}
+	writeSGFProp(b, "RU", ru)
+	writeSGFProp(b, "KM", strconv.FormatFloat(state.rules.Komi, 'f', -1, 64))
+	if state.handicap > 0 {
+		fmt.Fprintf(b, "HA[%d]", state.handicap)
+	}
+	for _, prop := range []struct {
+		name   string
+		player Player
+	}{{"AB", PlayerBlack}, {"AW", PlayerWhite}} {
+		points := l.coords(state.stones[prop.player])
+		if len(points) == 0 {
+			continue
+		}
+		b.WriteString(prop.name)
+		for _, p := range points {
+			fmt.Fprintf(b, "[%s]", sgfCoord(p[0], p[1]))
+		}
+	}
+	first := PlayerBlack
+	if state.handicap > 0 {
+		first = PlayerWhite
+	}
+	if state.nextMovePlayer != first {
+		fmt.Fprintf(b, "PL[%c]", state.nextMovePlayer.String()[0])
+	}
+}
+
+// writeSGFAction writes a move or a pass as B[cd] or W[]
+func writeSGFAction(b *strings.Builder, action *Action) {
+	fmt.Fprintf(b, "%c[", action.player.String()[0])
+	if !action.IsPass() {
+		b.WriteString(sgfCoord(int(action.x), int(action.y)))
+	}
+	b.WriteString("]")
+}
+
+// writeSGFProp writes the property unless the value is empty
+func writeSGFProp(b *strings.Builder, name, value string) {
+	if value == "" {
+		return
+	}
+	r := strings.NewReplacer("\\", "\\\\", "]", "\\]")
+	fmt.Fprintf(b, "%s[%s]", name, r.Replace(value))
+}
+
+// sgfCoord returns the SGF point of x, y, the column first
+func sgfCoord(x, y int) string {
+	return string([]byte{byte('a' + y), byte('a' + x)})
+}
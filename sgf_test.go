@@ -0,0 +1,217 @@
+package algo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func Test_parseSGF(t *testing.T) {
+	root, err := ParseSGF(`(;FF[4]GM[1]SZ[5]C[hello \] world\\
+]
+	;B[cc](;W[bb];B[]C[pass](;W[tt]))(;W[dd]))`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root.Prop("SZ") != "5" || root.Prop("C") != "hello ] world\\\n" {
+		t.Error("wrong root props:", root.Props)
+	}
+	b := root.Children[0]
+	if b.Prop("B") != "cc" || len(b.Children) != 2 {
+		t.Fatal("wrong variations at cc:", b.Props, len(b.Children))
+	}
+	if b.Children[0].Prop("W") != "bb" || b.Children[1].Prop("W") != "dd" {
+		t.Error("wrong variations:", b.Children[0].Props, b.Children[1].Props)
+	}
+	for _, data := range []string{"", "(", "(;B[aa]", "(;B[aa)", "(;B)", "(B[aa])"} {
+		if _, err := ParseSGF(data); err == nil {
+			t.Error("should not parse:", data)
+		}
+	}
+}
+
+func Test_sgfState(t *testing.T) {
+	root, err := ParseSGF(`(;SZ[7:5]KM[6.5]RU[Japanese]AB[aa:ab]AW[ca]
+		;W[ba];B[da];W[];B[bb];W[];B[cb](;W[ea])(;W[eb]))`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st, err := root.State()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Width() != 7 || st.Height() != 5 || st.Rules().Name != RulesJapanese.Name || st.Rules().Komi != 6.5 {
+		t.Error("wrong board or rules:", st.Width(), st.Height(), st.Rules())
+	}
+	// white ba and ca are taken by black cb
+	e := setupBoard(RulesJapanese,
+		"X..XO..",
+		"XXX....",
+		".......",
+		".......",
+		".......",
+	)
+	if st.GetBoard().String() != e.GetBoard().String() {
+		t.Error("board should be", e.GetBoard(), "but:", st.GetBoard())
+	}
+	if st.MoveNumber() != 7 || st.Captures(PlayerBlack) != 2 {
+		t.Error("wrong record:", st.MoveNumber(), st.Captures(PlayerBlack))
+	}
+
+	root, _ = ParseSGF(`(;SZ[9]HA[2]KM[0.5]AB[cc][gg];W[ee])`)
+	st, err = root.State()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Handicap() != 2 || st.Rules().Komi != 0.5 || st.NextPlayer() != PlayerBlack {
+		t.Error("wrong handicap game:", st.Handicap(), st.Rules().Komi, st.NextPlayer())
+	}
+
+	root, _ = ParseSGF(`(;SZ[5];B[cc];B[dd])`)
+	if _, err := root.State(); !errors.Is(err, ErrWrongPlayer) {
+		t.Error("second black move should be rejected, but:", err)
+	}
+	root, _ = ParseSGF(`(;SZ[5];B[ff])`)
+	if _, err := root.State(); !errors.Is(err, ErrOutOfBounds) {
+		t.Error("ff should be out of board, but:", err)
+	}
+}
+
+func Test_sgfTree(t *testing.T) {
+	root, err := ParseSGF(`(;SZ[5]C[start];B[cc](;W[bb]C[main];B[])(;W[dd]C[other]))`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := root.Tree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.Comment() != "start" {
+		t.Error("wrong root comment:", tree.Comment())
+	}
+	cc := tree.FindChild(2, 2)
+	bb, dd := cc.FindChild(1, 1), cc.FindChild(3, 3)
+	if bb.Comment() != "main" || dd.Comment() != "other" {
+		t.Error("wrong comments:", bb.Comment(), dd.Comment())
+	}
+	if bb.FindPass() == nil || cc.FindChild(2, 3).Comment() != "" {
+		t.Error("wrong children of the variations")
+	}
+
+	// symmetric variations at the root stay apart
+	root, _ = ParseSGF(`(;SZ[9](;B[cc]C[first];W[gg])(;B[gc]C[second];W[aa]))`)
+	tree, err = root.Tree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, second := tree.findChild(2, 2), tree.findChild(2, 6)
+	if len(tree.children) != 2 || first == nil || second == nil {
+		t.Fatal("both variations should be kept, but:", tree.children)
+	}
+	if first.Comment() != "first" || first.findChild(6, 6) == nil ||
+		second.Comment() != "second" || second.findChild(0, 0) == nil {
+		t.Error("wrong variations:", first.children, second.children)
+	}
+
+	root, _ = ParseSGF(`(;SZ[5];B[cc];AW[aa];W[bb])`)
+	if _, err := root.Tree(); err == nil {
+		t.Error("setup after the root should be rejected")
+	}
+}
+
+func Test_writeSGF(t *testing.T) {
+	root, err := NewRectTree("", 7, 5, RulesJapanese)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := root.PlaceFreeHandicap([][2]int{{3, 1}, {1, 5}}); err != nil {
+		t.Fatal(err)
+	}
+	root.SetComment("start [2]")
+	node := root
+	for _, action := range []*Action{
+		NewAction(2, 3, PlayerWhite),
+		NewAction(1, 2, PlayerBlack),
+		NewPassAction(PlayerWhite),
+	} {
+		if node, err = node.Play(action); err != nil {
+			t.Fatal(err)
+		}
+	}
+	node.SetComment(`pass \ here`)
+	node = node.Resign()
+	var b strings.Builder
+	if err := node.WriteSGF(&b, SGFInfo{Black: "b", White: "w", Date: "2020-01-02"}); err != nil {
+		t.Fatal(err)
+	}
+	expect := `(;FF[4]GM[1]CA[UTF-8]AP[algo]SZ[7:5]RU[Japanese]KM[6.5]HA[2]AB[fb][bd]` +
+		`PB[b]PW[w]DT[2020-01-02]RE[W+R]C[start [2\]]` + "\n;W[dc]\n;B[cb]\n;W[]C[pass \\\\ here])\n"
+	if b.String() != expect {
+		t.Errorf("sgf should be\n%s\nbut:\n%s", expect, b.String())
+	}
+
+	sgf, err := ParseSGF(b.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	st, err := sgf.State()
+	if err != nil {
+		t.Fatal(err)
+	}
+	played := node.GetState()
+	if st.Hash() != played.Hash() || st.Handicap() != 2 || st.Rules() != played.Rules() {
+		t.Error("sgf should read back the game, but:", st, st.Rules())
+	}
+	tree, err := sgf.Tree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.Comment() != "start [2]" {
+		t.Error("wrong root comment:", tree.Comment())
+	}
+}
+
+func Test_writeSGFTree(t *testing.T) {
+	root, _ := NewRectTree("", 5, 5, RulesChinese)
+	cc := root.FindChild(2, 2)
+	for i := 0; i < 3; i++ {
+		cc.FindChild(1, 1).backpropagate(PlayerBlack)
+	}
+	cc.FindChild(3, 3).backpropagate(PlayerWhite)
+	root.FindChild(0, 0).backpropagate(PlayerBlack)
+	cc.SetComment("center")
+
+	var b strings.Builder
+	if err := root.WriteSGFTree(&b, 2, 0); err != nil {
+		t.Fatal(err)
+	}
+	expect := "(;FF[4]GM[1]CA[UTF-8]AP[algo]SZ[5]RU[Chinese]KM[7.5]C[visits 5, wins B 4 W 1, uct 0.000]" +
+		"\n;B[cc]C[center\nvisits 4, wins B 3 W 1, uct 1.185]" +
+		"\n;W[bb]C[visits 3, wins B 3 W 0, uct 1.256])\n"
+	if b.String() != expect {
+		t.Errorf("sgf should be\n%s\nbut:\n%s", expect, b.String())
+	}
+
+	b.Reset()
+	if err := cc.WriteSGFTree(&b, 1, 1); err != nil {
+		t.Fatal(err)
+	}
+	expect = "(;FF[4]GM[1]CA[UTF-8]AP[algo]SZ[5]RU[Chinese]KM[7.5]C[visits 5, wins B 4 W 1, uct 0.000]" +
+		"\n;B[cc]C[center\nvisits 4, wins B 3 W 1, uct 1.185]" +
+		"\n(;W[bb]C[visits 3, wins B 3 W 0, uct 1.256])" +
+		"\n(;W[dd]C[visits 1, wins B 0 W 1, uct 1.776]))\n"
+	if b.String() != expect {
+		t.Errorf("sgf should be\n%s\nbut:\n%s", expect, b.String())
+	}
+	sgf, err := ParseSGF(b.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := sgf.Tree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.FindChild(2, 2).FindChild(3, 3).Comment() != "visits 1, wins B 0 W 1, uct 1.776" {
+		t.Error("variation should be read back")
+	}
+}
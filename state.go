@@ -1,68 +1,105 @@
 package algo
 
 import (
+	"errors"
 	"fmt"
-	"math"
 	"strconv"
 	"strings"
 )
 
 type Board [][]BoardStatus
 
+// Size returns the height of the board, which is the width as well
+// on a square board.
 func (b Board) Size() int {
 	return len(b)
 }
 
-func isStarPos(x, y, size int) bool {
-	b := size / 2
-	if x == b && y == b {
-		return true
+// Width returns the number of columns.
+func (b Board) Width() int {
+	if len(b) == 0 {
+		return 0
 	}
-	sq := int(math.Sqrt(float64(b)))
-	if sq <= 1 {
-		return false
+	return len(b[0])
+}
+
+// Height returns the number of rows.
+func (b Board) Height() int {
+	return len(b)
+}
+
+func isStarPos(x, y, height, width int) bool {
+	return onLines(x, starLines(height)) && onLines(y, starLines(width))
+}
+
+// starEdges is how far the star lines lie from the edge on each board
+// size, the boards left out have the middle line alone
+var starEdges = map[int]int{
+	8: 2, 9: 2, 10: 2, 11: 2,
+	12: 3, 13: 3, 14: 3, 15: 3, 16: 3, 17: 3, 18: 3,
+	19: 3, 20: 3, 21: 3, 22: 3, 23: 3, 24: 3, 25: 3,
+}
+
+// starLines returns the lines star points lie on, the middle line
+// alone on small boards, or the low, middle and high lines
+func starLines(size int) []int {
+	edge, ok := starEdges[size]
+	if !ok {
+		return []int{size / 2}
 	}
-	if (x == b || x == sq || size-x-1 == sq) &&
-		(y == b || y == sq || size-y-1 == sq) {
-		return true
+	return []int{edge, size / 2, size - edge - 1}
+}
+
+func onLines(i int, lines []int) bool {
+	for _, l := range lines {
+		if i == l {
+			return true
+		}
 	}
 	return false
 }
 
 func (board Board) String() string {
 	str := "\n   "
-	for x := range board {
-		str += string('a'+x) + " "
+	for y := 0; y < board.Width(); y++ {
+		str += string(rune('a'+y)) + " "
 	}
 	for x := range board {
 		str += fmt.Sprintf("\n%2d ", x+1)
 		for y := range board[x] {
-			var c string
-			switch board[x][y] {
-			case BoardStatusBlack:
-				c = "\u26AB"
-			case BoardStatusWhite:
-				c = "\u26AA"
-			case BoardStatusEmpty:
-				if isStarPos(x, y, board.Size()) {
-					c = "\u205C"
-				} else {
-					c = "\u253C"
-				}
-				if y < len(board[x])-1 {
-					c += "\u2500"
-				}
-			case BoardStatusForbidden:
-				c = "*\u2500"
-			default:
-				c = "?\u2500"
-			}
-			str += c
+			str += board.cell(x, y)
 		}
 	}
 	return str
 }
 
+// cell returns how the point x, y is drawn, with the line to the next
+// point of the row
+func (board Board) cell(x, y int) string {
+	var c string
+	switch board[x][y] {
+	case BoardStatusBlack:
+		c = "\u26AB"
+	case BoardStatusWhite:
+		c = "\u26AA"
+	case BoardStatusEmpty:
+		if isStarPos(x, y, board.Height(), board.Width()) {
+			c = "\u205C"
+		} else {
+			c = "\u253C"
+		}
+		if y < len(board[x])-1 {
+			c += "\u2500"
+		}
+	case BoardStatusForbidden:
+		c = "*\u2500"
+	default:
+		c = "?\u2500"
+	}
+	return c
+}
+
+// BoardSize is the width and height of a square board
 type BoardSize int
 
 const (
@@ -72,6 +109,21 @@ const (
 	BoardSizeMini   BoardSize = 5
 )
 
+// minBoardSize is the smallest width or height of a board
+const minBoardSize = 2
+
+// checkBoardSize tells whether a board of width and height can be played
+func checkBoardSize(width, height int) error {
+	if width < minBoardSize || width > maxBoardSize ||
+		height < minBoardSize || height > maxBoardSize {
+		return fmt.Errorf(
+			"invalid board size %dx%d, width and height should be %d-%d",
+			width, height, minBoardSize, maxBoardSize,
+		)
+	}
+	return nil
+}
+
 // BoardStatus fill all board position
 type BoardStatus uint8
 
@@ -87,254 +139,491 @@ const (
 
 // Space is a search space
 type State struct {
-	size           BoardSize
-	board          Board
+	layout *layout
+	// stones of each player
+	stones [2]bitboard
+	chain  []chainPoint
+	// ko is the point retaking a ko, -1 for none
+	ko             int
 	nextMovePlayer Player
+	// passes counts the consecutive pass moves ending at this state
+	passes int
+	// resigned is set once the last mover has resigned
+	resigned bool
+
+	// captures counts the stones each player has captured,
+	// prisoners adds the pass stones handed over to them
+	captures  [2]int
+	prisoners [2]int
+	handicap  int
+
+	// hash is the zobrist hash of the stones on board
+	hash    uint64
+	history *position
+	rules   Ruleset
+
+	// undo records the moves made by Play
+	undo []undoRecord
+	// lifeCache is the life of the stones on board once found
+	lifeCache *life
+	// scoreCache is the final score once estimated
+	scoreCache *finalScore
 }
 
 // NewSpace ...
-func NewState(size BoardSize) *State {
+func NewState(size BoardSize, rules Ruleset) *State {
+	state, err := NewRectState(int(size), int(size), rules)
+	if err != nil {
+		panic(err)
+	}
+	return state
+}
+
+// NewRectState makes the state of an empty board of width columns
+// and height rows, both 2-25.
+func NewRectState(width, height int, rules Ruleset) (*State, error) {
+	if err := checkBoardSize(width, height); err != nil {
+		return nil, err
+	}
+	l := layoutOf(width, height)
 	return &State{
-		size:           size,
-		board:          NewBoard(size),
+		layout:         l,
+		chain:          newChains(l),
+		ko:             -1,
 		nextMovePlayer: PlayerBlack,
-	}
+		history:        &position{player: PlayerBlack},
+		rules:          rules,
+	}, nil
 }
 
 func NewBoard(size BoardSize) Board {
-	board := make([][]BoardStatus, size)
+	return NewRectBoard(int(size), int(size))
+}
+
+// NewRectBoard makes an empty board of width columns and height rows.
+func NewRectBoard(width, height int) Board {
+	board := make([][]BoardStatus, height)
 	for i := range board {
-		board[i] = make([]BoardStatus, size)
+		board[i] = make([]BoardStatus, width)
 	}
 	return board
 }
 
+// sizeName is the size of a square board, or the width and height
+// like 7x9
+func (state *State) sizeName() string {
+	l := state.layout
+	if l.width == l.height {
+		return strconv.Itoa(l.width)
+	}
+	return fmt.Sprintf("%dx%d", l.width, l.height)
+}
+
+// ParseBoardSize reads a board size like 19 for a square board or
+// 7x9 for the width and height.
+func ParseBoardSize(s string) (width, height int, err error) {
+	parts := strings.SplitN(strings.ToLower(strings.TrimSpace(s)), "x", 2)
+	width, err = strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid board size: %s", s)
+	}
+	height = width
+	if len(parts) == 2 {
+		height, err = strconv.Atoi(parts[1])
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid board size: %s", s)
+		}
+	}
+	return width, height, checkBoardSize(width, height)
+}
+
+// Width returns the number of columns of the board.
+func (state *State) Width() int {
+	return state.layout.width
+}
+
+// Height returns the number of rows of the board.
+func (state *State) Height() int {
+	return state.layout.height
+}
+
+// GetBoard returns a copy of the board, the ko point is forbidden.
 func (state *State) GetBoard() Board {
-	return state.board
+	board := NewRectBoard(state.layout.width, state.layout.height)
+	for x := range board {
+		for y := range board[x] {
+			board[x][y] = state.at(x, y)
+		}
+	}
+	return board
+}
+
+func (state *State) String() string {
+	return state.GetBoard().String()
+}
+
+func (state *State) at(x, y int) BoardStatus {
+	return state.status(state.layout.index(x, y))
+}
+
+func (state *State) status(i int) BoardStatus {
+	switch {
+	case state.stones[PlayerBlack].has(i):
+		return BoardStatusBlack
+	case state.stones[PlayerWhite].has(i):
+		return BoardStatusWhite
+	case i == state.ko:
+		return BoardStatusForbidden
+	}
+	return BoardStatusEmpty
+}
+
+// occupied is the set of all stones
+func (state *State) occupied() bitboard {
+	return state.stones[PlayerBlack].or(state.stones[PlayerWhite])
+}
+
+// empty is the set of points without stone
+func (state *State) empty() bitboard {
+	return state.layout.mask.andNot(state.occupied())
+}
+
+// Hash returns the zobrist hash of the stones on board.
+func (state *State) Hash() uint64 {
+	return state.hash
+}
+
+// NextPlayer returns the player to move.
+func (state *State) NextPlayer() Player {
+	return state.nextMovePlayer
+}
+
+// MoveNumber returns how many actions have been played.
+func (state *State) MoveNumber() int {
+	return state.history.number
+}
+
+// LastMove returns the last action played, nil at the start.
+func (state *State) LastMove() *Action {
+	return state.history.action
+}
+
+// Moves returns the actions played from the start in order.
+func (state *State) Moves() []*Action {
+	moves := make([]*Action, state.history.number)
+	for p := state.history; p.action != nil; p = p.prev {
+		moves[p.number-1] = p.action
+	}
+	return moves
+}
+
+// Captures returns how many stones player has captured.
+func (state *State) Captures(player Player) int {
+	return state.captures[player]
+}
+
+// Prisoners returns the captures of player with the pass stones
+// handed over to them.
+func (state *State) Prisoners(player Player) int {
+	return state.prisoners[player]
+}
+
+// Rules returns the rules the game is played under.
+func (state *State) Rules() Ruleset {
+	return state.rules
 }
 
 func (state *State) GetLegalActions() (actions []*Action) {
-	for x := range state.board {
-		for y := range state.board[x] {
-			action := NewAction(x, y, state.nextMovePlayer)
-			if !state.isForbidden(action) {
-				actions = append(actions, action)
-			}
+	if state.IsGameOver() {
+		return
+	}
+	for _, i := range state.empty().points() {
+		x, y := state.layout.point(i)
+		action := NewAction(x, y, state.nextMovePlayer)
+		if !state.isForbidden(action) {
+			actions = append(actions, action)
 		}
 	}
+	actions = append(actions, NewPassAction(state.nextMovePlayer))
 	return
 }
 
 func (state *State) MoveTo(action *Action) *State {
-	ns := NewState(state.size)
-	ns.nextMovePlayer = state.nextMovePlayer.next()
-	for x := range state.board {
-		for y := range state.board[x] {
-			ns.board[x][y] = state.board[x][y]
-		}
-	}
-	ns.board[action.x][action.y] = state.nextMovePlayer.BoardStatus()
-	ns.clean(int(action.x), int(action.y))
+	ns := state.clone()
+	ns.play(action)
 	return ns
 }
 
-func (state *State) isForbidden(action *Action) bool {
-	if state.board[action.x][action.y] != BoardStatusEmpty {
-		return true
+// play makes action on state without checking it, and returns the
+// stones taken off, which are the own chain on a suicide
+func (state *State) play(action *Action) (removed []int, suicide bool) {
+	player := state.nextMovePlayer
+	state.nextMovePlayer = player.next()
+	state.ko = -1
+	switch {
+	case action.IsResign():
+		state.resigned = true
+	case action.IsPass():
+		// a pass lifts the ko restriction
+		state.passes++
+		if state.rules.PassStone {
+			state.prisoners[player.next()]++
+		}
+	default:
+		state.passes = 0
+		removed, suicide = state.place(state.layout.index(int(action.x), int(action.y)), player)
 	}
-	state.board[action.x][action.y] = state.nextMovePlayer.BoardStatus()
-	defer func() {
-		state.board[action.x][action.y] = BoardStatusEmpty
-	}()
-	nv := NewBoard(state.size)
-	x, y := int(action.x), int(action.y)
-	nv[x][y] = BoardStatusTrue
-	judge := &Judge{
-		sets:   [][2]int{{x, y}},
-		status: state.board[action.x][action.y],
-		visit:  nv,
-		state:  state,
-	}
-	judge.isAlive(x, y)
-	if judge.alive {
-		return false
+	state.history = &position{
+		hash:   state.hash,
+		player: state.nextMovePlayer,
+		action: action,
+		number: state.history.number + 1,
+		prev:   state.history,
 	}
-	// deal da jie
-	if len(judge.sets) == 1 {
-		for _, np := range pRange(judge.state.board.Size(), x, y) {
-			judge.sets = [][2]int{{x, y}}
-			judge.status = state.nextMovePlayer.next().BoardStatus()
-			judge.isAlive(np[0], np[1])
-			if !judge.alive {
-				return false
+	return
+}
+
+// repeats reports whether the move breaks the superko rule, by the
+// hash of the position it makes, without playing it
+func (state *State) repeats(action *Action) bool {
+	player := state.nextMovePlayer
+	hash := state.hashAfter(state.layout.index(int(action.x), int(action.y)), player)
+	for p := state.history; p != nil; p = p.prev {
+		if p.hash != hash {
+			continue
+		}
+		switch state.rules.Ko {
+		case KoRulePositional:
+			return true
+		case KoRuleSituational:
+			if p.player == player.next() {
+				return true
 			}
 		}
 	}
-	return true
+	return false
 }
 
-func (state *State) hasResult() bool {
-	var b, w, t int
-	for x := range state.board {
-		for y := range state.board[x] {
-			t++
-			switch state.board[x][y] {
-			case BoardStatusBlack:
-				b++
-			case BoardStatusWhite:
-				w++
+// hashAfter returns the hash of the stones once player moves at the
+// empty point i, taking off the chains around left without liberty
+func (state *State) hashAfter(i int, player Player) uint64 {
+	l := state.layout
+	all := state.occupied()
+	hash := state.hash ^ l.zobrist[i][player]
+	var seen []int
+	captured, suicide := false, true
+	for _, n := range l.neighbors(i) {
+		if n < 0 {
+			continue
+		}
+		if !all.has(n) {
+			suicide = false
+			continue
+		}
+		root := state.root(n)
+		if hasPoint(seen, root) {
+			continue
+		}
+		seen = append(seen, root)
+		// a chain in atari next to the empty point i has it as
+		// its last liberty
+		atari := state.inAtari(root)
+		switch {
+		case state.stones[player].has(n):
+			if !atari {
+				suicide = false
+			}
+		case atari:
+			captured = true
+			for _, s := range state.chainStones(root) {
+				hash ^= l.zobrist[s][player.next()]
 			}
 		}
 	}
-	return b > t/2 || w > t/2
-}
-
-func (state *State) Result() Player {
-	var base int
-	for x := range state.board {
-		for y := range state.board[x] {
-			if state.guess(x, y) == PlayerBlack {
-				base++
-				if base > 185 {
-					return PlayerBlack
-				}
+	if suicide && !captured {
+		// the own chain joined at i is taken off
+		hash ^= l.zobrist[i][player]
+		for _, root := range seen {
+			if !state.stones[player].has(root) {
+				continue
+			}
+			for _, s := range state.chainStones(root) {
+				hash ^= l.zobrist[s][player]
 			}
 		}
 	}
-	return PlayerWhite
+	return hash
 }
 
-func (state *State) guess(x, y int) Player {
-	// find all edge node, calc which is more
-	var base int
-	visit := NewBoard(state.size)
-	for _, action := range state.findEdges(x, y, visit) {
-		if action.player == PlayerBlack {
-			base++
-		} else {
-			base--
+func hasPoint(points []int, i int) bool {
+	for _, p := range points {
+		if p == i {
+			return true
 		}
 	}
-	if base >= 0 {
-		return PlayerBlack
+	return false
+}
+
+// errors of an action that cannot be played
+var (
+	ErrGameOver    = errors.New("game is over")
+	ErrWrongPlayer = errors.New("not the player to move")
+	ErrOutOfBounds = errors.New("point is out of board")
+	ErrOccupied    = errors.New("point is occupied")
+	ErrKo          = errors.New("ko cannot be retaken yet")
+	ErrSuicide     = errors.New("suicide is not allowed")
+)
+
+// CheckAction returns why action cannot be played on state, wrapping
+// one of the Err values, or nil for a legal action. A move repeating
+// an earlier position under superko gives ErrKo.
+func (state *State) CheckAction(action *Action) error {
+	if state.IsGameOver() {
+		return ErrGameOver
+	}
+	if action.player != state.nextMovePlayer {
+		return fmt.Errorf("%w: %s", ErrWrongPlayer, action.player)
+	}
+	if action.IsPass() || action.IsResign() {
+		return nil
+	}
+	if int(action.x) >= state.layout.height || int(action.y) >= state.layout.width {
+		return fmt.Errorf("%w: %s", ErrOutOfBounds, action)
+	}
+	i := state.layout.index(int(action.x), int(action.y))
+	switch {
+	case state.occupied().has(i):
+		return fmt.Errorf("%w: %s", ErrOccupied, action)
+	case i == state.ko:
+		return fmt.Errorf("%w: %s", ErrKo, action)
+	case state.isIllegalPoint(i):
+		return fmt.Errorf("%w: %s", ErrSuicide, action)
+	case state.rules.Ko != KoRuleSimple && state.repeats(action):
+		return fmt.Errorf("%w: %s repeats a position under %s superko", ErrKo, action, state.rules.Ko)
 	}
-	return PlayerWhite
+	return nil
 }
 
-func (state *State) findEdges(x, y int, visit Board) []*Action {
-	visit[x][y] = BoardStatusTrue
-	var res []*Action
-	for _, np := range pRange(state.board.Size(), x, y) {
-		nx, ny := np[0], np[1]
-		if visit[nx][ny] == BoardStatusTrue {
-			continue
-		}
-		switch state.board[nx][ny] {
-		case BoardStatusBlack:
-			visit[nx][ny] = BoardStatusTrue
-			res = append(res, NewAction(nx, ny, PlayerBlack))
-		case BoardStatusWhite:
-			visit[nx][ny] = BoardStatusTrue
-			res = append(res, NewAction(nx, ny, PlayerWhite))
-		default:
-			res = append(res, state.findEdges(nx, ny, visit)...)
+func (state *State) isForbidden(action *Action) bool {
+	if action.IsPass() || action.IsResign() {
+		return false
+	}
+	if state.isIllegalPoint(state.layout.index(int(action.x), int(action.y))) {
+		return true
+	}
+	if state.rules.Ko == KoRuleSimple {
+		return false
+	}
+	return state.repeats(action)
+}
+
+func (state *State) isIllegalPoint(i int) bool {
+	all := state.occupied()
+	if all.has(i) || i == state.ko {
+		return true
+	}
+	neighbors := state.layout.neighbors(i)
+	for _, n := range neighbors {
+		if n >= 0 && !all.has(n) {
+			return false
 		}
 	}
-	return res
-}
-
-func (state *State) clean(cx, cy int) {
-	visit := NewBoard(state.size)
-	for x := range visit {
-		for y := range visit[x] {
-			if visit[x][y] == BoardStatusFalse {
-				visit[x][y] = BoardStatusTrue
-				switch state.board[x][y] {
-				case BoardStatusEmpty:
-				case BoardStatusForbidden:
-					state.board[x][y] = BoardStatusEmpty
-				case BoardStatusBlack, BoardStatusWhite:
-					nv := NewBoard(state.size)
-					nv[x][y] = BoardStatusTrue
-					judge := &Judge{
-						sets:   [][2]int{{x, y}},
-						status: state.board[x][y],
-						visit:  nv,
-						state:  state,
-					}
-					judge.deal(cx, cy)
-				}
+	player := state.nextMovePlayer
+	joined := false
+	for _, n := range neighbors {
+		if n < 0 {
+			continue
+		}
+		atari := state.inAtari(state.root(n))
+		if state.stones[player].has(n) {
+			// the own chain keeps a liberty other than i
+			if !atari {
+				return false
 			}
+			joined = true
+		} else if atari {
+			// deal da jie, no liberty left is fine when capturing
+			return false
 		}
 	}
+	return !state.rules.Suicide || !joined
 }
 
-func (judge *Judge) isAlive(cx, cy int) {
-	next := false
-	for _, p := range judge.sets {
-		for _, np := range pRange(judge.state.board.Size(), p[0], p[1]) {
-			x, y := np[0], np[1]
-			if judge.visit[x][y] == BoardStatusTrue {
-				continue
-			}
-			switch judge.state.board[x][y] {
-			case judge.status:
-				judge.visit[x][y] = BoardStatusTrue
-				judge.sets = append(judge.sets, [2]int{x, y})
-				next = true
-			case BoardStatusEmpty, BoardStatusForbidden:
-				judge.visit[x][y] = BoardStatusTrue
-				judge.alive = true
-			}
-		}
+// IsGameOver reports whether the game has ended by two consecutive passes
+// or a resignation.
+func (state *State) IsGameOver() bool {
+	return state.passes >= 2 || state.resigned
+}
+
+// IsResigned reports whether the game has ended by a resignation.
+func (state *State) IsResigned() bool {
+	return state.resigned
+}
+
+func (state *State) hasResult() bool {
+	if state.IsGameOver() {
+		return true
 	}
-	if next {
-		judge.isAlive(cx, cy)
+	t := state.layout.width * state.layout.height
+	b := state.stones[PlayerBlack].count()
+	w := state.stones[PlayerWhite].count()
+	if b > t/2 || w > t/2 {
+		return true
 	}
+	return state.layout.mask.andNot(state.life().settled()).isEmpty()
 }
 
-type Judge struct {
-	sets   [][2]int // (x, y )
-	status BoardStatus
+// place puts a stone of player at i, and takes the chains next to it
+// left without liberty, the opponent ones first, so that a capture
+// saves the own chain
+func (state *State) place(i int, player Player) (removed []int, suicide bool) {
+	state.put(i, player)
+	for _, n := range state.layout.neighbors(i) {
+		if n < 0 || !state.stones[player.next()].has(n) {
+			continue
+		}
+		if root := state.find(n); state.chain[root].libs == 0 {
+			removed = append(removed, state.removeChain(root)...)
+		}
+	}
+	own := state.find(i)
+	if state.chain[own].libs == 0 {
+		log.Debug("remove suicide chain at:", i)
+		return state.removeChain(own), true
+	}
+	if len(removed) == 1 && state.chain[own].size == 1 && state.inAtari(own) {
+		state.ko = removed[0]
+	}
+	return removed, false
+}
 
-	alive bool
-	visit Board
-	state *State
+// put places a stone of player at i
+func (state *State) put(i int, player Player) {
+	state.stones[player].set(i)
+	state.hash ^= state.layout.zobrist[i][player]
+	state.link(i, player)
 }
 
-func (judge *Judge) deal(cx, cy int) {
-	judge.isAlive(cx, cy)
-	if !judge.alive {
-		log.Debug("remove not alive:", judge.sets)
-		if len(judge.sets) == 1 {
-			p := judge.sets[0]
-			x, y := p[0], p[1]
-			if cx != x || cy != y {
-				judge.state.board[x][y] = BoardStatusForbidden
-			} else {
-				log.Info("jie is here, keep this, deal the other one")
-			}
-		} else {
-			for _, p := range judge.sets {
-				x, y := p[0], p[1]
-				judge.state.board[x][y] = BoardStatusEmpty
-			}
+// remove takes the stone at i off the board as a prisoner
+func (state *State) remove(i int) {
+	for _, player := range []Player{PlayerBlack, PlayerWhite} {
+		if state.stones[player].has(i) {
+			state.stones[player].clear(i)
+			state.hash ^= state.layout.zobrist[i][player]
+			state.captures[player.next()]++
+			state.prisoners[player.next()]++
 		}
 	}
-
-	log.Debug("judge:", judge.sets)
-	log.Debug("visit:", judge.visit)
-	log.Debug("board:", judge.state.board)
 }
 
-func pRange(size, x, y int) (p [][2]int) {
+func pRange(height, width, x, y int) (p [][2]int) {
 	if y-1 >= 0 {
 		p = append(p, [2]int{x, y - 1})
 	}
-	if x+1 < size {
+	if x+1 < height {
 		p = append(p, [2]int{x + 1, y})
 	}
-	if y+1 < size {
+	if y+1 < width {
 		p = append(p, [2]int{x, y + 1})
 	}
 	if x-1 >= 0 {
@@ -350,6 +639,9 @@ const (
 	PlayerWhite
 )
 
+// PlayerNone is the winner of a jigo
+const PlayerNone Player = -1
+
 func (p Player) next() Player {
 	if p == PlayerBlack {
 		return PlayerWhite
@@ -373,13 +665,29 @@ func (p Player) String() string {
 		return "Black"
 	case PlayerWhite:
 		return "White"
+	case PlayerNone:
+		return "None"
 	}
 	return "unknown"
 }
 
+type actionKind uint8
+
+const (
+	actionMove actionKind = iota
+	actionPass
+	actionResign
+)
+
+var actionNames = map[actionKind]string{
+	actionPass:   "pass",
+	actionResign: "resign",
+}
+
 type Action struct {
-	x uint8
-	y uint8
+	x    uint8
+	y    uint8
+	kind actionKind
 
 	player Player
 }
@@ -392,6 +700,37 @@ func NewAction(x, y int, player Player) *Action {
 	}
 }
 
+// NewPassAction returns a pass for player.
+func NewPassAction(player Player) *Action {
+	return &Action{
+		kind:   actionPass,
+		player: player,
+	}
+}
+
+// NewResignAction returns a resignation of player.
+func NewResignAction(player Player) *Action {
+	return &Action{
+		kind:   actionResign,
+		player: player,
+	}
+}
+
+func (action *Action) IsPass() bool {
+	return action.kind == actionPass
+}
+
+func (action *Action) IsResign() bool {
+	return action.kind == actionResign
+}
+
+func (action *Action) equal(other *Action) bool {
+	return action.kind == other.kind &&
+		action.x == other.x &&
+		action.y == other.y &&
+		action.player == other.player
+}
+
 func (action *Action) Detail() (x, y uint8, player Player) {
 	return action.x, action.y, action.player
 }
@@ -400,27 +739,48 @@ func (action *Action) String() string {
 	if action == nil {
 		return "nil"
 	}
+	if name, ok := actionNames[action.kind]; ok {
+		return fmt.Sprintf("%sp%d", name, action.player)
+	}
 	return fmt.Sprintf("x%dy%dp%d", action.x, action.y, action.player)
 }
 
 func (action *Action) FromString(str string) error {
+	for kind, name := range actionNames {
+		if !strings.HasPrefix(str, name) {
+			continue
+		}
+		rest := str[len(name):]
+		if !strings.HasPrefix(rest, "p") {
+			return fmt.Errorf("invalid string: %s", str)
+		}
+		action.x = 0
+		action.y = 0
+		action.kind = kind
+		return action.playerFromString(rest[1:])
+	}
 	xi := strings.Index(str, "x")
 	yi := strings.Index(str, "y")
 	pi := strings.Index(str, "p")
-	if xi < 0 || yi < 0 || pi < 0 {
+	if xi < 0 || yi < xi || pi < yi {
 		return fmt.Errorf("invalid string: %s", str)
 	}
-	x, err := strconv.Atoi(str[xi:yi])
+	x, err := strconv.Atoi(str[xi+1 : yi])
 	if err != nil {
 		return fmt.Errorf("invalid string: %s %v", str, err)
 	}
-	y, err := strconv.Atoi(str[yi:pi])
+	y, err := strconv.Atoi(str[yi+1 : pi])
 	if err != nil {
 		return fmt.Errorf("invalid string: %s %v", str, err)
 	}
 	action.x = uint8(x)
 	action.y = uint8(y)
-	switch str[pi:] {
+	action.kind = actionMove
+	return action.playerFromString(str[pi+1:])
+}
+
+func (action *Action) playerFromString(str string) error {
+	switch str {
 	case "0":
 		action.player = PlayerBlack
 	case "1":
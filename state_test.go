@@ -1,7 +1,10 @@
 package algo
 
 import (
+	"math/rand"
+	"strings"
 	"testing"
+	"time"
 )
 
 var (
@@ -11,71 +14,74 @@ var (
 
 func Test_clean1(t *testing.T) {
 	//SetLogLevel(Debug)
-	b := [][]int{{0, 1}, {1, 0}, {1, 2}, {2, 1}}
+	b := [][]int{{0, 1}, {1, 0}, {1, 2}}
 	w := [][]int{{1, 1}}
-	e := [][]int{}
-	f := w
-	testClean(t, b, w, e, f)
+	m := []int{2, 1}
+	e := w
+	f := [][]int{}
+	testClean(t, b, w, m, e, f)
 }
 
 func Test_clean2(t *testing.T) {
-	b := [][]int{{0, 1}, {0, 2}, {1, 0}, {1, 3}, {2, 1}, {2, 2}}
+	b := [][]int{{0, 1}, {0, 2}, {1, 0}, {1, 3}, {2, 1}}
 	w := [][]int{{1, 1}, {1, 2}}
+	m := []int{2, 2}
 	e := w
 	f := [][]int{}
-	testClean(t, b, w, e, f)
+	testClean(t, b, w, m, e, f)
 }
 
 func Test_clean3(t *testing.T) {
-	b := [][]int{{0, 1}, {1, 0}}
+	b := [][]int{{0, 1}}
 	w := [][]int{{0, 0}}
-	e := [][]int{}
-	f := w
-	testClean(t, b, w, e, f)
+	m := []int{1, 0}
+	e := w
+	f := [][]int{}
+	testClean(t, b, w, m, e, f)
 }
 
 func Test_clean4(t *testing.T) {
-	st := NewState(19)
-	st.board[18][18] = BoardStatusBlack
-	st.clean(18, 18)
-	if st.board[18][18] != BoardStatusBlack {
-		t.Error("18-18 should be keep, but:", st.board)
+	st := NewState(19, RulesChinese)
+	st.place(st.layout.index(18, 18), PlayerBlack)
+	if st.at(18, 18) != BoardStatusBlack {
+		t.Error("18-18 should be keep, but:", st)
 	}
 }
 
 func Test_tijie(t *testing.T) {
-	st := NewState(19)
+	st := NewState(19, RulesChinese)
 	for _, p := range [][]int{{0, 1}, {1, 0}, {1, 2}, {2, 1}} {
-		st.board[p[0]][p[1]] = BoardStatusBlack
+		st.put(st.layout.index(p[0], p[1]), PlayerBlack)
 	}
-	for _, p := range [][]int{{1, 1}, {2, 0}, {2, 2}, {3, 1}} {
-		st.board[p[0]][p[1]] = BoardStatusWhite
+	for _, p := range [][]int{{2, 0}, {2, 2}, {3, 1}} {
+		st.put(st.layout.index(p[0], p[1]), PlayerWhite)
 	}
-	st.clean(1, 1)
-	if st.board[2][1] != BoardStatusForbidden {
-		t.Error("2-1 should be clean, but:", st.board)
+	st.place(st.layout.index(1, 1), PlayerWhite)
+	if st.at(2, 1) != BoardStatusForbidden {
+		t.Error("2-1 should be clean, but:", st)
 	}
-	if st.board[1][1] != BoardStatusWhite {
-		t.Error("1-1 should be keep, but:", st.board)
+	if st.at(1, 1) != BoardStatusWhite {
+		t.Error("1-1 should be keep, but:", st)
 	}
 }
 
-func testClean(t *testing.T, b, w, e, f [][]int) {
-	st := NewState(19)
+// testClean puts stones b and w, then black moves at m
+func testClean(t *testing.T, b, w [][]int, m []int, e, f [][]int) {
+	st := NewState(19, RulesChinese)
 	for _, p := range b {
-		st.board[p[0]][p[1]] = BoardStatusBlack
+		st.put(st.layout.index(p[0], p[1]), PlayerBlack)
 	}
 	for _, p := range w {
-		st.board[p[0]][p[1]] = BoardStatusWhite
+		st.put(st.layout.index(p[0], p[1]), PlayerWhite)
 	}
-	st.clean(9, 9)
+	st.place(st.layout.index(m[0], m[1]), PlayerBlack)
 	for _, p := range e {
-		if st.board[p[0]][p[1]] != BoardStatusEmpty {
+		if st.at(p[0], p[1]) != BoardStatusEmpty {
 			t.Errorf("%d-%d should be clean to empty, but:%s\n", p[0], p[1], st.GetBoard())
 		}
 	}
 	for _, p := range f {
-		if st.board[p[0]][p[1]] != BoardStatusForbidden {
+		if st.at(p[0], p[1]) != BoardStatusForbidden {
 			t.Errorf("%d-%d should clean to forbidden, but:%s\n", p[0], p[1], st.GetBoard())
 		}
 	}
@@ -92,7 +98,7 @@ func Test_pRange(t *testing.T) {
 }
 
 func assertPRange(t *testing.T, x, y int, e [][]int) {
-	r := pRange(19, x, y)
+	r := pRange(19, 19, x, y)
 	if len(r) != len(e) {
 		t.Error("test", x, y, "should:", e, "but:", r)
 	}
@@ -102,3 +108,310 @@ func assertPRange(t *testing.T, x, y int, e [][]int) {
 		}
 	}
 }
+
+func Test_pass(t *testing.T) {
+	st := NewState(19, RulesChinese)
+	st.ko = st.layout.index(2, 1)
+	st = st.MoveTo(NewPassAction(PlayerBlack))
+	if st.at(2, 1) != BoardStatusEmpty {
+		t.Error("2-1 should be released by pass, but:", st)
+	}
+	if st.IsGameOver() {
+		t.Error("one pass should not end the game")
+	}
+	st = st.MoveTo(NewAction(3, 3, PlayerWhite))
+	st = st.MoveTo(NewPassAction(PlayerBlack))
+	if st.IsGameOver() {
+		t.Error("passes are not consecutive, game should go on")
+	}
+	st = st.MoveTo(NewPassAction(PlayerWhite))
+	if !st.IsGameOver() {
+		t.Error("two passes should end the game")
+	}
+	if len(st.GetLegalActions()) != 0 {
+		t.Error("finished game should have no legal actions")
+	}
+}
+
+func Test_actionString(t *testing.T) {
+	for _, a := range []*Action{
+		NewAction(3, 15, PlayerBlack),
+		NewAction(0, 0, PlayerWhite),
+		NewPassAction(PlayerWhite),
+		NewResignAction(PlayerBlack),
+	} {
+		b := &Action{}
+		if err := b.FromString(a.String()); err != nil {
+			t.Error("parse", a, "failed:", err)
+			continue
+		}
+		if !a.equal(b) {
+			t.Error("parse", a, "should be equal, but:", b)
+		}
+	}
+}
+
+func Test_superko(t *testing.T) {
+	for _, rule := range []KoRule{KoRuleSimple, KoRulePositional, KoRuleSituational} {
+		rules := RulesChinese
+		rules.Ko = rule
+		st := NewState(19, rules)
+		st = playMoves(st, [][]int{
+			{2, 1}, {2, 4}, {1, 2}, {1, 3}, {3, 2}, {3, 3}, nil, {2, 2}, {2, 3},
+		})
+		if st.at(2, 2) == BoardStatusWhite || st.at(2, 2) == BoardStatusBlack {
+			t.Error(rule, "2-2 should be captured, but:", st)
+		}
+		if !st.isForbidden(NewAction(2, 2, PlayerWhite)) {
+			t.Error(rule, "2-2 retake should be forbidden")
+		}
+		st = playMoves(st, [][]int{{10, 10}, {10, 11}})
+		if st.isForbidden(NewAction(2, 2, PlayerWhite)) {
+			t.Error(rule, "2-2 retake should be allowed after a ko threat")
+		}
+	}
+}
+
+func Test_hash(t *testing.T) {
+	st := NewState(19, RulesChinese)
+	st = playMoves(st, [][]int{
+		{2, 1}, {2, 4}, {1, 2}, {1, 3}, {3, 2}, {3, 3}, nil, {2, 2}, {2, 3},
+	})
+	var hash uint64
+	board := st.GetBoard()
+	for x := range board {
+		for y := range board[x] {
+			switch board[x][y] {
+			case BoardStatusBlack:
+				hash ^= zobrist(x, y, PlayerBlack)
+			case BoardStatusWhite:
+				hash ^= zobrist(x, y, PlayerWhite)
+			}
+		}
+	}
+	if hash != st.Hash() {
+		t.Errorf("hash should be %x, but: %x", hash, st.Hash())
+	}
+}
+
+// playMoves plays points in turn, nil for pass
+func playMoves(st *State, moves [][]int) *State {
+	for _, m := range moves {
+		if m == nil {
+			st = st.MoveTo(NewPassAction(st.nextMovePlayer))
+		} else {
+			st = st.MoveTo(NewAction(m[0], m[1], st.nextMovePlayer))
+		}
+	}
+	return st
+}
+
+func Test_suicide(t *testing.T) {
+	// black 0-0 and 0-1 are left with 0-2 as the last liberty
+	moves := [][]int{{0, 0}, {1, 0}, {0, 1}, {1, 1}, nil, {1, 2}, nil, {0, 3}}
+	st := playMoves(NewState(19, RulesChinese), moves)
+	if !st.isForbidden(NewAction(0, 2, PlayerBlack)) {
+		t.Error("0-2 suicide should be forbidden under chinese rules")
+	}
+	st = playMoves(NewState(19, RulesTrompTaylor), moves)
+	if st.isForbidden(NewAction(0, 2, PlayerBlack)) {
+		t.Fatal("0-2 suicide should be allowed under tromp-taylor rules")
+	}
+	st = st.MoveTo(NewAction(0, 2, PlayerBlack))
+	for _, p := range [][]int{{0, 0}, {0, 1}, {0, 2}} {
+		if st.at(p[0], p[1]) != BoardStatusEmpty {
+			t.Errorf("%d-%d should be removed, but:%s\n", p[0], p[1], st)
+		}
+	}
+	if st.prisoners[PlayerWhite] != 3 {
+		t.Error("white should take 3 prisoners, but:", st.prisoners)
+	}
+}
+
+func hasAction(actions []*Action, action *Action) bool {
+	for _, a := range actions {
+		if a.equal(action) {
+			return true
+		}
+	}
+	return false
+}
+
+func Test_suicideLegalActions(t *testing.T) {
+	moves := [][]int{{0, 0}, {1, 0}, {0, 1}, {1, 1}, nil, {1, 2}, nil, {0, 3}}
+	suicide := NewAction(0, 2, PlayerBlack)
+	st := playMoves(NewState(19, RulesChinese), moves)
+	if hasAction(st.GetLegalActions(), suicide) {
+		t.Error("0-2 suicide should not be generated under chinese rules")
+	}
+	st = playMoves(NewState(19, RulesNewZealand), moves)
+	if !hasAction(st.GetLegalActions(), suicide) {
+		t.Error("0-2 suicide should be generated under new zealand rules")
+	}
+	// a single stone suicide never changes the board
+	st = playMoves(NewState(19, RulesTrompTaylor), [][]int{{1, 0}, nil, {0, 1}})
+	if !st.isForbidden(NewAction(0, 0, PlayerWhite)) {
+		t.Error("single stone suicide at 0-0 should be forbidden")
+	}
+}
+
+func Test_capture(t *testing.T) {
+	// black 0-1 joins 0-0 into a group without liberty, but captures 0-2
+	st := playMoves(NewState(19, RulesChinese), [][]int{
+		{0, 0}, {1, 0}, {1, 2}, {1, 1}, {0, 3}, {0, 2},
+	})
+	if st.isForbidden(NewAction(0, 1, PlayerBlack)) {
+		t.Fatal("0-1 should be allowed as it captures")
+	}
+	st = st.MoveTo(NewAction(0, 1, PlayerBlack))
+	for _, p := range [][]int{{0, 0}, {0, 1}} {
+		if st.at(p[0], p[1]) != BoardStatusBlack {
+			t.Errorf("%d-%d should be kept, but:%s\n", p[0], p[1], st)
+		}
+	}
+	if st.at(0, 2) == BoardStatusWhite {
+		t.Error("0-2 should be captured, but:", st)
+	}
+}
+
+func Test_passStone(t *testing.T) {
+	st := NewState(19, RulesAGA).MoveTo(NewPassAction(PlayerBlack))
+	if st.prisoners[PlayerWhite] != 1 {
+		t.Error("black pass should hand white a prisoner, but:", st.prisoners)
+	}
+	st = NewState(19, RulesChinese).MoveTo(NewPassAction(PlayerBlack))
+	if st.prisoners[PlayerWhite] != 0 {
+		t.Error("pass should not hand any prisoner, but:", st.prisoners)
+	}
+}
+
+func Test_record(t *testing.T) {
+	st := NewState(19, RulesAGA)
+	if st.MoveNumber() != 0 || st.LastMove() != nil || len(st.Moves()) != 0 {
+		t.Fatal("new state should have no move")
+	}
+	st = playMoves(st, [][]int{
+		{0, 0}, {1, 0}, {1, 2}, {1, 1}, {0, 3}, {0, 2}, {0, 1}, nil,
+	})
+	moves := st.Moves()
+	if st.MoveNumber() != 8 || len(moves) != 8 {
+		t.Fatal("should have 8 moves, but:", st.MoveNumber(), len(moves))
+	}
+	if !moves[6].equal(NewAction(0, 1, PlayerBlack)) || !st.LastMove().IsPass() {
+		t.Error("wrong moves:", moves)
+	}
+	if st.Captures(PlayerBlack) != 1 || st.Captures(PlayerWhite) != 0 {
+		t.Error("black should have captured 1, but:", st.captures)
+	}
+	if st.Prisoners(PlayerBlack) != 2 {
+		t.Error("white pass should hand black a prisoner, but:", st.prisoners)
+	}
+	if st.NextPlayer() != PlayerBlack {
+		t.Error("black should be next, but:", st.NextPlayer())
+	}
+}
+
+func Test_resign(t *testing.T) {
+	st := NewState(19, RulesChinese).MoveTo(NewAction(3, 3, PlayerBlack))
+	st = st.MoveTo(NewResignAction(PlayerWhite))
+	if !st.IsGameOver() || !st.IsResigned() {
+		t.Error("resignation should end the game")
+	}
+	if st.Result() != PlayerBlack || st.ResultString() != "B+R" {
+		t.Error("black should win by resignation, but:", st.ResultString())
+	}
+}
+
+// benchGame plays a random game of at most n moves
+func benchGame(size BoardSize, n int) []*Action {
+	r := rand.New(rand.NewSource(1))
+	st := NewState(size, RulesChinese)
+	var moves []*Action
+	for len(moves) < n && !st.IsGameOver() {
+		actions := st.GetLegalActions()
+		action := actions[r.Intn(len(actions))]
+		st = st.MoveTo(action)
+		moves = append(moves, action)
+	}
+	return moves
+}
+
+func BenchmarkMoveTo(b *testing.B) {
+	SetLogLevel(Error)
+	moves := benchGame(BoardSizeLarge, 200)
+	b.ResetTimer()
+	start := time.Now()
+	for i := 0; i < b.N; i++ {
+		st := NewState(BoardSizeLarge, RulesChinese)
+		for _, action := range moves {
+			st = st.MoveTo(action)
+		}
+	}
+	b.ReportMetric(float64(b.N*len(moves))/time.Since(start).Seconds(), "moves/s")
+}
+
+func BenchmarkGetLegalActions(b *testing.B) {
+	SetLogLevel(Error)
+	st := NewState(BoardSizeLarge, RulesChinese)
+	for _, action := range benchGame(BoardSizeLarge, 120) {
+		st = st.MoveTo(action)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		st.GetLegalActions()
+	}
+}
+
+func Test_rectBoard(t *testing.T) {
+	for _, size := range [][2]int{{1, 5}, {5, 26}, {0, 0}} {
+		if _, err := NewRectState(size[0], size[1], RulesChinese); err == nil {
+			t.Error("board size should be invalid:", size)
+		}
+	}
+	// 7 columns and 9 rows
+	st, err := NewRectState(7, 9, RulesChinese)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Width() != 7 || st.Height() != 9 || st.sizeName() != "7x9" {
+		t.Fatal("size should be 7x9, but:", st.Width(), st.Height())
+	}
+	if n := len(st.GetLegalActions()); n != 64 {
+		t.Error("63 points and a pass should be legal, but:", n)
+	}
+	if err := st.Play(NewAction(8, 6, PlayerBlack)); err != nil {
+		t.Error("8-6 should be the last corner:", err)
+	}
+	if err := st.Play(NewAction(6, 8, PlayerWhite)); err == nil {
+		t.Error("6-8 should be out of board")
+	}
+	// white fills the corner of black at 8-6
+	st = playMoves(st, [][]int{{8, 5}, nil, {7, 6}})
+	if st.at(8, 6) != BoardStatusEmpty || st.Captures(PlayerWhite) != 1 {
+		t.Error("8-6 should be captured, but:", st)
+	}
+	board := st.GetBoard()
+	if board.Width() != 7 || board.Height() != 9 {
+		t.Error("board should be 7x9, but:", board.Width(), board.Height())
+	}
+	if !strings.HasPrefix(board.String(), "\n   a b c d e f g \n 1 ") {
+		t.Error("board should show 7 columns, but:", board)
+	}
+	if black, white := st.Area(); black != 0 || white != 63 {
+		t.Error("white should own the board, but:", black, white)
+	}
+}
+
+func Test_parseBoardSize(t *testing.T) {
+	for s, e := range map[string][2]int{"19": {19, 19}, "7x9": {7, 9}, "2X25": {2, 25}} {
+		w, h, err := ParseBoardSize(s)
+		if err != nil || w != e[0] || h != e[1] {
+			t.Error(s, "should be", e, "but:", w, h, err)
+		}
+	}
+	for _, s := range []string{"", "x", "1", "9x", "26x9"} {
+		if _, _, err := ParseBoardSize(s); err == nil {
+			t.Error(s, "should be invalid")
+		}
+	}
+}
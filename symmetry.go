@@ -0,0 +1,199 @@
+package algo
+
+import "fmt"
+
+// Symmetry is one of the 8 rotations and mirrors of a board
+type Symmetry int
+
+const (
+	SymmetryIdentity Symmetry = iota
+	// SymmetryRotate90 turns the board clockwise
+	SymmetryRotate90
+	SymmetryRotate180
+	SymmetryRotate270
+	// SymmetryFlipX mirrors the rows, top to bottom
+	SymmetryFlipX
+	// SymmetryFlipY mirrors the columns, left to right
+	SymmetryFlipY
+	// SymmetryTranspose swaps the rows and columns
+	SymmetryTranspose
+	// SymmetryAntiTranspose swaps them along the other diagonal
+	SymmetryAntiTranspose
+)
+
+// Symmetries lists the 8 symmetries, the identity first
+var Symmetries = []Symmetry{
+	SymmetryIdentity,
+	SymmetryRotate90,
+	SymmetryRotate180,
+	SymmetryRotate270,
+	SymmetryFlipX,
+	SymmetryFlipY,
+	SymmetryTranspose,
+	SymmetryAntiTranspose,
+}
+
+func (s Symmetry) String() string {
+	switch s {
+	case SymmetryIdentity:
+		return "identity"
+	case SymmetryRotate90:
+		return "rotate90"
+	case SymmetryRotate180:
+		return "rotate180"
+	case SymmetryRotate270:
+		return "rotate270"
+	case SymmetryFlipX:
+		return "flipx"
+	case SymmetryFlipY:
+		return "flipy"
+	case SymmetryTranspose:
+		return "transpose"
+	case SymmetryAntiTranspose:
+		return "antitranspose"
+	}
+	return "unknown"
+}
+
+// Inverse returns the symmetry taking the points back.
+func (s Symmetry) Inverse() Symmetry {
+	switch s {
+	case SymmetryRotate90:
+		return SymmetryRotate270
+	case SymmetryRotate270:
+		return SymmetryRotate90
+	}
+	return s
+}
+
+// swapsAxes tells whether rows become columns, which only fits a
+// square board
+func (s Symmetry) swapsAxes() bool {
+	switch s {
+	case SymmetryRotate90, SymmetryRotate270, SymmetryTranspose, SymmetryAntiTranspose:
+		return true
+	}
+	return false
+}
+
+// Point moves x, y on a board of width and height, the board must be
+// square for the symmetries swapping rows and columns.
+func (s Symmetry) Point(x, y, width, height int) (int, int) {
+	switch s {
+	case SymmetryRotate90:
+		return y, height - 1 - x
+	case SymmetryRotate180:
+		return height - 1 - x, width - 1 - y
+	case SymmetryRotate270:
+		return width - 1 - y, x
+	case SymmetryFlipX:
+		return height - 1 - x, y
+	case SymmetryFlipY:
+		return x, width - 1 - y
+	case SymmetryTranspose:
+		return y, x
+	case SymmetryAntiTranspose:
+		return width - 1 - y, height - 1 - x
+	}
+	return x, y
+}
+
+// Transform returns the action moved by s on a board of width and
+// height, a pass or a resignation stays the same.
+func (action *Action) Transform(s Symmetry, width, height int) *Action {
+	if action.kind != actionMove {
+		return action
+	}
+	x, y := s.Point(int(action.x), int(action.y), width, height)
+	return NewAction(x, y, action.player)
+}
+
+// symmetries returns the symmetries fitting the board
+func (state *State) symmetries() []Symmetry {
+	if state.layout.width == state.layout.height {
+		return Symmetries
+	}
+	var res []Symmetry
+	for _, s := range Symmetries {
+		if !s.swapsAxes() {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+// transformPoint moves the point i by s
+func (state *State) transformPoint(s Symmetry, i int) int {
+	l := state.layout
+	x, y := l.point(i)
+	x, y = s.Point(x, y, l.width, l.height)
+	return l.index(x, y)
+}
+
+// transformStones moves the stones of each player by s
+func (state *State) transformStones(s Symmetry) (stones [2]bitboard) {
+	for p := range stones {
+		for _, i := range state.stones[p].points() {
+			stones[p].set(state.transformPoint(s, i))
+		}
+	}
+	return
+}
+
+// Transform returns the state with the board moved by s, which must fit
+// the board. The new state starts its game record from this position.
+func (state *State) Transform(s Symmetry) (*State, error) {
+	if s.swapsAxes() && state.layout.width != state.layout.height {
+		return nil, fmt.Errorf("%s does not fit a %s board", s, state.sizeName())
+	}
+	ns := state.clone()
+	ns.stones = state.transformStones(s)
+	ns.hash = 0
+	for p := range ns.stones {
+		for _, i := range ns.stones[p].points() {
+			ns.hash ^= ns.layout.zobrist[i][p]
+		}
+	}
+	ns.relink(ns.occupied().points())
+	if state.ko >= 0 {
+		ns.ko = state.transformPoint(s, state.ko)
+	}
+	ns.history = &position{hash: ns.hash, player: ns.nextMovePlayer}
+	return ns, nil
+}
+
+// Canonical returns the state in the orientation with the smallest
+// hash, with the symmetry moving state to it, so that symmetric
+// positions have the same canonical form.
+func (state *State) Canonical() (*State, Symmetry) {
+	best, bestHash := SymmetryIdentity, state.hash
+	for _, s := range state.symmetries()[1:] {
+		var hash uint64
+		stones := state.transformStones(s)
+		for p := range stones {
+			for _, i := range stones[p].points() {
+				hash ^= state.layout.zobrist[i][p]
+			}
+		}
+		if hash < bestHash {
+			best, bestHash = s, hash
+		}
+	}
+	ns, _ := state.Transform(best)
+	return ns, best
+}
+
+// invariants returns the symmetries but the identity leaving the
+// stones and the ko point where they are
+func (state *State) invariants() []Symmetry {
+	var res []Symmetry
+	for _, s := range state.symmetries()[1:] {
+		if state.ko >= 0 && state.transformPoint(s, state.ko) != state.ko {
+			continue
+		}
+		if state.transformStones(s) == state.stones {
+			res = append(res, s)
+		}
+	}
+	return res
+}
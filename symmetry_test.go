@@ -0,0 +1,52 @@
+package algo
+
+import "testing"
+
+func Test_symmetryPoint(t *testing.T) {
+	for _, s := range Symmetries {
+		for _, p := range [][2]int{{0, 0}, {1, 3}, {4, 2}} {
+			x, y := s.Point(p[0], p[1], 5, 5)
+			x, y = s.Inverse().Point(x, y, 5, 5)
+			if x != p[0] || y != p[1] {
+				t.Errorf("%s inverse should take %v back, but: %d-%d", s, p, x, y)
+			}
+		}
+	}
+	if x, y := SymmetryRotate90.Point(0, 1, 5, 5); x != 1 || y != 4 {
+		t.Error("rotate90 should move 0-1 to 1-4, but:", x, y)
+	}
+	action := NewAction(0, 1, PlayerBlack).Transform(SymmetryFlipY, 7, 9)
+	if action.String() != NewAction(0, 5, PlayerBlack).String() {
+		t.Error("flipy should move 0-1 to 0-5 on a 7x9 board, but:", action)
+	}
+	if pass := NewPassAction(PlayerBlack); pass.Transform(SymmetryRotate90, 5, 5) != pass {
+		t.Error("pass should stay the same")
+	}
+}
+
+func Test_canonical(t *testing.T) {
+	st := playMoves(NewState(BoardSizeMini, RulesChinese), [][]int{{0, 1}, {2, 2}})
+	canon, _ := st.Canonical()
+	for _, s := range Symmetries {
+		ts, err := st.Transform(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkChains(t, ts)
+		if c, _ := ts.Canonical(); c.stones != canon.stones || c.hash != canon.hash {
+			t.Errorf("%s should have the same canonical form, but:%s%s", s, c, canon)
+		}
+	}
+	ts, _ := st.Transform(SymmetryRotate90)
+	if ts.at(1, 4) != BoardStatusBlack || ts.NextPlayer() != PlayerBlack {
+		t.Error("rotate90 should move black 0-1 to 1-4, but:", ts)
+	}
+
+	rect, _ := NewRectState(7, 9, RulesChinese)
+	if _, err := rect.Transform(SymmetryTranspose); err == nil {
+		t.Error("transpose should not fit a 7x9 board")
+	}
+	if n := len(rect.symmetries()); n != 4 {
+		t.Error("7x9 board should have 4 symmetries, but:", n)
+	}
+}
@@ -1,21 +1,32 @@
 package main
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/mapleque/algo"
 )
 
 func main() {
-	root := algo.NewTree("model", algo.BoardSizeSmall)
+	root := algo.NewTree("model", algo.BoardSizeSmall, algo.RulesChinese)
 	algo.SetLogLevel(algo.Info)
 	root.LoadCheckpoint()
-	go root.MCTS()
+	root.MCTS()
 	saveCheckpoint(root)
 }
 
+// the tree dumped for browsing keeps the nodes visited this
+// many times, down to this depth
+const (
+	sgfMinVisits = 100
+	sgfMaxDepth  = 8
+)
+
 func saveCheckpoint(root *algo.TreeNode) {
 	<-time.After(10 * time.Second)
 	root.SaveCheckpoint()
+	if err := root.SaveSGFTree("model.sgf", sgfMinVisits, sgfMaxDepth); err != nil {
+		fmt.Printf("failed to save the tree: %v\n", err)
+	}
 	saveCheckpoint(root)
 }
@@ -10,7 +10,11 @@ import (
 )
 
 type Context struct {
-	stop bool
+	// resign when the win rate stays below resignThreshold
+	// for resignMoves consecutive moves
+	resignThreshold float64
+	resignMoves     int
+	lowMoves        [2]int
 }
 
 // TreeNode is a search tree
@@ -31,15 +35,49 @@ type TreeNode struct {
 
 	state    *State
 	children []*TreeNode
+
+	// ownership sums 1 for Black and -1 for White owning each point
+	// at the end of the rollouts, kept on the nodes MCTS runs from
+	ownership []int
+	owned     int
+
+	// comment is read from or written to SGF files
+	comment string
 }
 
 // NewTree ...
-func NewTree(ckfile string, size BoardSize) *TreeNode {
+func NewTree(ckfile string, size BoardSize, rules Ruleset) *TreeNode {
+	root, err := NewRectTree(ckfile, int(size), int(size), rules)
+	if err != nil {
+		panic(err)
+	}
+	return root
+}
+
+// NewRectTree makes a tree from an empty board of width columns and
+// height rows, the checkpoint file is named with the size, rules and komi.
+func NewRectTree(ckfile string, width, height int, rules Ruleset) (*TreeNode, error) {
+	state, err := NewRectState(width, height, rules)
+	if err != nil {
+		return nil, err
+	}
 	return &TreeNode{
-		ckfile: fmt.Sprintf("%s.%d.ck", ckfile, size),
+		ckfile: fmt.Sprintf("%s.%s.ck", ckfile, strings.Join(state.checkpointKey(), ".")),
 		ctx:    &Context{},
-		state:  NewState(size),
+		state:  state,
+	}, nil
+}
+
+// NewTreeFromState makes a tree to search from a copy of state, which
+// must be valid. The tree has no checkpoint file.
+func NewTreeFromState(state *State) (*TreeNode, error) {
+	if err := state.Validate(); err != nil {
+		return nil, err
 	}
+	return &TreeNode{
+		ctx:   &Context{},
+		state: state.clone(),
+	}, nil
 }
 
 func (root *TreeNode) newChildFromAction(action *Action) *TreeNode {
@@ -56,20 +94,36 @@ func (root *TreeNode) expand() {
 	if root.children == nil {
 		root.children = []*TreeNode{}
 	}
+	syms := root.collapsed()
 	total := 0
 	for _, action := range root.state.GetLegalActions() {
-		if root.findChild(int(action.x), int(action.y)) == nil {
-			root.children = append(
-				root.children,
-				root.newChildFromAction(action),
-			)
-			total++
+		if root.findAction(action) != nil {
+			continue
 		}
+		if sym, _ := root.findSymmetric(action, syms); sym != nil {
+			continue
+		}
+		root.children = append(
+			root.children,
+			root.newChildFromAction(action),
+		)
+		total++
 	}
 	log.Tracef("expand found %d new ations", total)
 	root.updateTotal(int64(total))
 }
 
+// collapsed returns the symmetries expand leaves the moves out by:
+// symmetric moves of the head lead to the same game, unless the
+// history makes them differ under superko
+func (root *TreeNode) collapsed() []Symmetry {
+	if root.parent == nil &&
+		(root.state.rules.Ko == KoRuleSimple || root.state.history.prev == nil) {
+		return root.state.invariants()
+	}
+	return nil
+}
+
 func (root *TreeNode) updateTotal(total int64) {
 	if total == 0 {
 		return
@@ -87,13 +141,72 @@ func (root *TreeNode) updateState() {
 	}
 }
 
+// FindChild returns the child reached by a move at x, y, nil when
+// it cannot be played.
 func (root *TreeNode) FindChild(x, y int) *TreeNode {
+	node, err := root.Play(NewAction(x, y, root.state.nextMovePlayer))
+	if err != nil {
+		return nil
+	}
+	return node
+}
+
+// Play returns the child reached by action, or the error of
+// State.CheckAction when it cannot be played.
+func (root *TreeNode) Play(action *Action) (*TreeNode, error) {
+	if err := root.state.CheckAction(action); err != nil {
+		return nil, err
+	}
+	if action.IsResign() {
+		return root.Resign(), nil
+	}
 	root.expand()
-	return root.findChild(x, y)
+	node := root.findAction(action)
+	if node == nil {
+		// left out by expand for a symmetric one, which is turned
+		// to the action with what it has searched
+		sym, s := root.findSymmetric(action, root.collapsed())
+		if sym == nil {
+			return nil, fmt.Errorf("no child for the action: %s", action)
+		}
+		sym.reorient(s.Inverse())
+		node = sym
+	}
+	return node, nil
+}
+
+// reorient moves the moves under node by s, which leaves the position
+// of its parent unchanged, so that the statistics stay with them
+func (node *TreeNode) reorient(s Symmetry) {
+	l := node.state.layout
+	node.action = node.action.Transform(s, l.width, l.height)
+	node.state = node.parent.state.MoveTo(node.action)
+	if node.ownership != nil {
+		own := make([]int, len(node.ownership))
+		for x := 0; x < l.height; x++ {
+			for y := 0; y < l.width; y++ {
+				i := l.index(x, y)
+				own[node.state.transformPoint(s, i)] = node.ownership[i]
+			}
+		}
+		node.ownership = own
+	}
+	for _, child := range node.children {
+		child.reorient(s)
+	}
+}
+
+// FindPass returns the child reached by passing.
+func (root *TreeNode) FindPass() *TreeNode {
+	root.expand()
+	return root.findAction(NewPassAction(root.state.nextMovePlayer))
 }
 
 func (root *TreeNode) findChild(x, y int) *TreeNode {
 	for _, node := range root.children {
+		if node.action.IsPass() {
+			continue
+		}
 		if int(node.action.x) == x && int(node.action.y) == y {
 			return node
 		}
@@ -101,6 +214,27 @@ func (root *TreeNode) findChild(x, y int) *TreeNode {
 	return nil
 }
 
+// findSymmetric returns the child of an action moved by one of syms,
+// with the symmetry
+func (root *TreeNode) findSymmetric(action *Action, syms []Symmetry) (*TreeNode, Symmetry) {
+	l := root.state.layout
+	for _, s := range syms {
+		if node := root.findAction(action.Transform(s, l.width, l.height)); node != nil {
+			return node, s
+		}
+	}
+	return nil, SymmetryIdentity
+}
+
+func (root *TreeNode) findAction(action *Action) *TreeNode {
+	for _, node := range root.children {
+		if node.action.equal(action) {
+			return node
+		}
+	}
+	return nil
+}
+
 func (root *TreeNode) NextPlayer() Player {
 	return root.state.nextMovePlayer
 }
@@ -113,6 +247,16 @@ func (root *TreeNode) GetState() *State {
 	return root.state
 }
 
+// Comment returns the comment of the node, as read from an SGF file.
+func (root *TreeNode) Comment() string {
+	return root.comment
+}
+
+// SetComment sets the comment written to SGF files for the node.
+func (root *TreeNode) SetComment(comment string) {
+	root.comment = comment
+}
+
 func (root *TreeNode) GetChildren() []*TreeNode {
 	return root.children
 }
@@ -167,7 +311,7 @@ func (root *TreeNode) SaveCheckpoint() {
 		panic(err)
 	}
 	defer f.Close()
-	f.Write([]byte(fmt.Sprintf("%d\n", head.state.size)))
+	f.Write([]byte(strings.Join(head.state.checkpointKey(), " ") + "\n"))
 	write(head, f)
 	log.Infof("save checkpoint finished with root: %s", head)
 }
@@ -197,24 +341,37 @@ func (root *TreeNode) LoadCheckpoint() {
 		panic(err)
 	}
 	rd := bufio.NewReader(f)
-	// first line is size
+	// first line is size, rules and komi
 	line, err := readline(rd)
 	if err != nil {
 		panic(err)
 	}
 
-	size, err := strconv.Atoi(line)
+	key := strings.Fields(line)
+	if len(key) != 3 {
+		panic(fmt.Sprintf("invalid ck first line:\n\t\"%s\"", line))
+	}
+	width, height, err := ParseBoardSize(key[0])
 	if err != nil {
 		panic(fmt.Sprintf("invalid ck first line:\n\t\"%s\"", line))
 	}
-	if int(root.state.size) != size {
+	if root.state.Width() != width || root.state.Height() != height {
 		panic(fmt.Sprintf(
-			"different size checkpoint file is loading, need: %d, but %d",
-			root.state.size,
-			size,
+			"different size checkpoint file is loading, need: %s, but %s",
+			root.state.sizeName(),
+			line,
 		))
 	}
-	log.Tracef("found size %d checkpoint, start read lines", size)
+	// the statistics and the stored moves only hold for the rules
+	// and komi they were searched with
+	if need := strings.Join(root.state.checkpointKey(), " "); line != need {
+		panic(fmt.Sprintf(
+			"different rules checkpoint file is loading, need: %s, but %s",
+			need,
+			line,
+		))
+	}
+	log.Tracef("found size %s checkpoint, start read lines", line)
 	// build tree
 	cknodes := map[string]*CkNode{}
 	total := 0
@@ -242,7 +399,7 @@ func (root *TreeNode) LoadCheckpoint() {
 			root.result = ckn.r
 			nodes[id] = root
 		} else {
-			node := ckn.newTreeNode(size)
+			node := ckn.newTreeNode(root)
 			nodes[id] = node
 		}
 	}
@@ -321,8 +478,13 @@ func newCkNode(line string) (*CkNode, error) {
 	return ckn, nil
 }
 
-func (ckn *CkNode) newTreeNode(size int) *TreeNode {
-	node := NewTree("", BoardSize(size))
+// newTreeNode makes a node of the tree under root, its state is
+// updated once the tree is built
+func (ckn *CkNode) newTreeNode(root *TreeNode) *TreeNode {
+	node := &TreeNode{
+		ctx:   root.ctx,
+		state: root.state,
+	}
 	node.action = &Action{}
 	_ = node.action.FromString(ckn.a)
 	if ckn.u == 1 {
@@ -334,6 +496,16 @@ func (ckn *CkNode) newTreeNode(size int) *TreeNode {
 	return node
 }
 
+// checkpointKey returns the board size, rules name and komi that the
+// statistics of a checkpoint are searched with
+func (state *State) checkpointKey() []string {
+	return []string{
+		state.sizeName(),
+		state.rules.Name,
+		strconv.FormatFloat(state.rules.Komi, 'f', -1, 64),
+	}
+}
+
 func readline(rd *bufio.Reader) (string, error) {
 	var (
 		isPrefix bool  = true
@@ -0,0 +1,44 @@
+package algo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_checkpointRules(t *testing.T) {
+	SetLogLevel(Error)
+	prefix := filepath.Join(t.TempDir(), "model")
+	root, _ := NewRectTree(prefix, 5, 5, RulesChinese)
+	root.FindChild(2, 2).FindChild(1, 1)
+	root.SaveCheckpoint()
+	if filepath.Base(root.ckfile) != "model.5.chinese.7.5.ck" {
+		t.Error("checkpoint should be named with the rules and komi, but:", root.ckfile)
+	}
+
+	loaded, _ := NewRectTree(prefix, 5, 5, RulesChinese)
+	loaded.LoadCheckpoint()
+	if loaded.findChild(2, 2) == nil || loaded.findChild(2, 2).findChild(1, 1) == nil {
+		t.Fatal("checkpoint should be loaded")
+	}
+	if loaded.findChild(2, 2).findChild(1, 1).ctx != loaded.ctx {
+		t.Error("loaded nodes should share the context of the root")
+	}
+
+	rules := RulesChinese
+	rules.Komi = 0.5
+	other, _ := NewRectTree(prefix, 5, 5, rules)
+	data, err := os.ReadFile(root.ckfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(other.ckfile, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("a checkpoint of another komi should be refused")
+		}
+	}()
+	other.LoadCheckpoint()
+}
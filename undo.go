@@ -0,0 +1,80 @@
+package algo
+
+import "fmt"
+
+// undoRecord keeps what a move changed on the state
+type undoRecord struct {
+	action *Action
+	// removed stones, the own chain on a suicide
+	removed []int
+	suicide bool
+
+	player    Player
+	ko        int
+	passes    int
+	resigned  bool
+	captures  [2]int
+	prisoners [2]int
+	hash      uint64
+	history   *position
+}
+
+// Play makes a legal action on state in place, Undo takes it back.
+// The error of an illegal action is one of those of CheckAction.
+func (state *State) Play(action *Action) error {
+	if err := state.CheckAction(action); err != nil {
+		return err
+	}
+	record := undoRecord{
+		action:    action,
+		player:    state.nextMovePlayer,
+		ko:        state.ko,
+		passes:    state.passes,
+		resigned:  state.resigned,
+		captures:  state.captures,
+		prisoners: state.prisoners,
+		hash:      state.hash,
+		history:   state.history,
+	}
+	record.removed, record.suicide = state.play(action)
+	state.undo = append(state.undo, record)
+	return nil
+}
+
+// Undo takes back the last action made by Play.
+func (state *State) Undo() error {
+	if len(state.undo) == 0 {
+		return fmt.Errorf("no action to undo")
+	}
+	record := state.undo[len(state.undo)-1]
+	state.undo = state.undo[:len(state.undo)-1]
+	if record.action.kind == actionMove {
+		l := state.layout
+		i := l.index(int(record.action.x), int(record.action.y))
+		color := record.player.next()
+		if record.suicide {
+			color = record.player
+		}
+		state.stones[record.player].clear(i)
+		around := l.neighbors(i)
+		affected := around[:]
+		for _, s := range record.removed {
+			if s != i {
+				state.stones[color].set(s)
+			}
+			n := l.neighbors(s)
+			affected = append(affected, s)
+			affected = append(affected, n[:]...)
+		}
+		state.relink(affected)
+	}
+	state.nextMovePlayer = record.player
+	state.ko = record.ko
+	state.passes = record.passes
+	state.resigned = record.resigned
+	state.captures = record.captures
+	state.prisoners = record.prisoners
+	state.hash = record.hash
+	state.history = record.history
+	return nil
+}
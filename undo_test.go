@@ -0,0 +1,150 @@
+package algo
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func Test_undo(t *testing.T) {
+	for _, rules := range []Ruleset{RulesChinese, RulesTrompTaylor} {
+		st := NewState(BoardSizeSmall, rules)
+		var snapshots []State
+		for _, action := range benchGame(BoardSizeSmall, 300) {
+			action.player = st.nextMovePlayer
+			snapshot := *st
+			if err := st.Play(action); err != nil {
+				continue
+			}
+			snapshots = append(snapshots, snapshot)
+			checkChains(t, st)
+		}
+		for k := len(snapshots) - 1; k >= 0; k-- {
+			if err := st.Undo(); err != nil {
+				t.Fatal(err)
+			}
+			checkChains(t, st)
+			want := snapshots[k]
+			if st.stones != want.stones || st.hash != want.hash ||
+				st.ko != want.ko || st.prisoners != want.prisoners ||
+				st.nextMovePlayer != want.nextMovePlayer || st.history != want.history {
+				t.Fatalf("undo %d should restore:%s\nbut:%s", k, &want, st)
+			}
+		}
+		if err := st.Undo(); err == nil {
+			t.Error("undo on the new board should fail")
+		}
+	}
+}
+
+func Test_undoSuicide(t *testing.T) {
+	st := playMoves(NewState(19, RulesTrompTaylor), [][]int{
+		{0, 0}, {1, 0}, {0, 1}, {1, 1}, nil, {1, 2}, nil, {0, 3},
+	})
+	before := *st
+	if err := st.Play(NewAction(0, 2, PlayerBlack)); err != nil {
+		t.Fatal(err)
+	}
+	if st.at(0, 0) != BoardStatusEmpty {
+		t.Fatal("suicide should remove 0-0, but:", st)
+	}
+	if err := st.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	checkChains(t, st)
+	if st.stones != before.stones || st.hash != before.hash || st.prisoners != before.prisoners {
+		t.Error("undo should restore:", &before, "but:", st)
+	}
+}
+
+func Test_hashAfter(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, rules := range []Ruleset{RulesTrompTaylor, RulesAGA} {
+		st := NewState(BoardSizeMini, rules)
+		for k := 0; k < 200 && !st.IsGameOver(); k++ {
+			for _, i := range st.empty().points() {
+				x, y := st.layout.point(i)
+				if st.isIllegalPoint(i) {
+					continue
+				}
+				want := st.MoveTo(NewAction(x, y, st.nextMovePlayer)).hash
+				if hash := st.hashAfter(i, st.nextMovePlayer); hash != want {
+					t.Fatalf("hash after %d-%d should be %x, but: %x%s", x, y, want, hash, st)
+				}
+			}
+			actions := st.GetLegalActions()
+			st = st.MoveTo(actions[r.Intn(len(actions))])
+		}
+	}
+}
+
+func Test_playErrors(t *testing.T) {
+	st := NewState(BoardSizeMini, RulesChinese)
+	if err := st.Play(NewAction(0, 0, PlayerWhite)); !errors.Is(err, ErrWrongPlayer) {
+		t.Error("white should not move first, but:", err)
+	}
+	if err := st.Play(NewAction(5, 0, PlayerBlack)); !errors.Is(err, ErrOutOfBounds) {
+		t.Error("5-0 should be out of board, but:", err)
+	}
+	if err := st.Play(NewAction(0, 0, PlayerBlack)); err != nil {
+		t.Error(err)
+	}
+	if err := st.Play(NewAction(0, 0, PlayerWhite)); !errors.Is(err, ErrOccupied) {
+		t.Error("0-0 should be taken, but:", err)
+	}
+
+	// black 0-2 takes white 0-1 in a ko
+	st = playMoves(NewState(BoardSizeMini, RulesChinese), [][]int{
+		{0, 0}, {1, 2}, {1, 1}, {0, 3}, nil, {0, 1}, {0, 2},
+	})
+	if err := st.Play(NewAction(0, 1, PlayerWhite)); !errors.Is(err, ErrKo) {
+		t.Error("0-1 should retake the ko, but:", err, st)
+	}
+	// black 0-0 and 0-1 have 0-2 as the last liberty
+	st = playMoves(NewState(BoardSizeMini, RulesChinese), [][]int{
+		{0, 0}, {1, 0}, {0, 1}, {1, 1}, nil, {1, 2}, nil, {0, 3},
+	})
+	if err := st.Play(NewAction(0, 2, PlayerBlack)); !errors.Is(err, ErrSuicide) {
+		t.Error("0-2 should be suicide, but:", err, st)
+	}
+	st = playMoves(st, [][]int{nil, nil})
+	if err := st.Play(NewPassAction(PlayerWhite)); !errors.Is(err, ErrGameOver) {
+		t.Error("game should be over, but:", err)
+	}
+}
+
+func Test_treePlay(t *testing.T) {
+	root := NewTree("", BoardSizeMini, RulesChinese)
+	node, err := root.Play(NewAction(2, 2, PlayerBlack))
+	if err != nil || node.GetAction().String() != NewAction(2, 2, PlayerBlack).String() {
+		t.Fatal("2-2 should be played, but:", node, err)
+	}
+	if _, err := node.Play(NewAction(2, 2, PlayerWhite)); !errors.Is(err, ErrOccupied) {
+		t.Error("2-2 should be taken, but:", err)
+	}
+	if node, _ := node.Play(NewResignAction(PlayerWhite)); !node.GetState().IsResigned() {
+		t.Error("white should resign")
+	}
+}
+
+func BenchmarkPlayUndo(b *testing.B) {
+	SetLogLevel(Error)
+	moves := benchGame(BoardSizeLarge, 200)
+	st := NewState(BoardSizeLarge, RulesChinese)
+	b.ResetTimer()
+	start := time.Now()
+	for i := 0; i < b.N; i++ {
+		for _, action := range moves {
+			if err := st.Play(action); err != nil {
+				b.Fatal(err)
+			}
+		}
+		for range moves {
+			if err := st.Undo(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	b.ReportMetric(float64(b.N*len(moves))/time.Since(start).Seconds(), "moves/s")
+}
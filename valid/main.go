@@ -8,7 +8,7 @@ import (
 )
 
 func main() {
-	root := algo.NewTree("model", algo.BoardSizeSmall)
+	root := algo.NewTree("model", algo.BoardSizeSmall, algo.RulesChinese)
 	algo.SetLogLevel(algo.Trace)
 	root.LoadCheckpoint()
 
@@ -0,0 +1,38 @@
+package algo
+
+import "math/rand"
+
+// maxBoardSize is the largest board the zobrist table covers
+const maxBoardSize = 25
+
+// zobristSeed keeps hashes stable between runs
+const zobristSeed = 0x5a0b1257
+
+var zobristTable = newZobristTable()
+
+func newZobristTable() (table [maxBoardSize][maxBoardSize][2]uint64) {
+	r := rand.New(rand.NewSource(zobristSeed))
+	for x := range table {
+		for y := range table[x] {
+			for p := range table[x][y] {
+				table[x][y][p] = r.Uint64()
+			}
+		}
+	}
+	return
+}
+
+// zobrist is the hash key of a stone of player at (x, y)
+func zobrist(x, y int, player Player) uint64 {
+	return zobristTable[x][y][player]
+}
+
+// position is one entry of the game history, shared between states
+type position struct {
+	hash   uint64
+	player Player // player to move
+	// action led to this position, nil for the start
+	action *Action
+	number int
+	prev   *position
+}